@@ -0,0 +1,136 @@
+package dhpals
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// GroupAudit is the result of the analysis of DH group parameters
+// against small-subgroup confinement and Pollard's kangaroo attacks.
+type GroupAudit struct {
+	Name string
+
+	// PIsPrime and QIsPrime report whether p and Q are (probably) prime.
+	PIsPrime bool
+	QIsPrime bool
+	// GeneratorHasOrderQ reports whether g generates the subgroup of order Q.
+	GeneratorHasOrderQ bool
+
+	// Cofactor is (p-1)/Q.
+	Cofactor *big.Int
+	// SmallFactors are the prime factors of the cofactor below the trial division bound.
+	SmallFactors []dhgroup.Factor
+	// LargeFactors are the known prime factors of the cofactor above the bound.
+	LargeFactors []dhgroup.Factor
+	// SmoothPart is the product of SmallFactors.
+	SmoothPart *big.Int
+	// Unfactored is the part of the cofactor that has not been factored.
+	Unfactored *big.Int
+
+	// LeakableBits is the number of private key bits that can be recovered
+	// via small-subgroup confinement, i.e. log2(min(SmoothPart, Q)).
+	LeakableBits float64
+	// OracleQueries is the number of DH oracle queries the confinement attack needs
+	// in the worst case, that is the sum of the small factors.
+	OracleQueries *big.Int
+	// KangarooWork is log2 of the expected number of group operations
+	// that Pollard's kangaroo algorithm needs to recover the rest of the key.
+	KangarooWork float64
+}
+
+// AuditGroup analyses the group parameters. The cofactor is factored starting from
+// the factors known to the group and then by trial division with primes up to bound.
+func AuditGroup(g *dhgroup.GroupParams, bound uint32) *GroupAudit {
+	a := &GroupAudit{
+		Name:          g.Name,
+		PIsPrime:      g.P.ProbablyPrime(20),
+		QIsPrime:      g.Q.ProbablyPrime(20),
+		Cofactor:      g.Cofactor(),
+		SmoothPart:    big.NewInt(1),
+		OracleQueries: big.NewInt(0),
+	}
+
+	a.GeneratorHasOrderQ = a.QIsPrime && g.G.Cmp(Big1) != 0 &&
+		new(big.Int).Exp(g.G, g.Q, g.P).Cmp(Big1) == 0
+
+	known, rest := g.CofactorFactors()
+	for _, f := range known {
+		if !f.Prime.ProbablyPrime(20) {
+			rest.Mul(rest, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exp)), nil))
+			continue
+		}
+		a.addFactor(f, bound)
+	}
+
+	a.Unfactored = big.NewInt(1)
+	if rest.Cmp(Big1) > 0 {
+		for _, f := range factorizeBound(rest, bound) {
+			if f.fact.IsUint64() && f.fact.Uint64() <= uint64(bound) || f.fact.ProbablyPrime(20) {
+				a.addFactor(dhgroup.Factor{Prime: f.fact, Exp: int(f.exp)}, bound)
+			} else {
+				a.Unfactored.Mul(a.Unfactored, new(big.Int).Exp(f.fact, big.NewInt(f.exp), nil))
+			}
+		}
+	}
+
+	a.LeakableBits = math.Min(log2(a.SmoothPart), log2(g.Q))
+
+	// The kangaroo takes about 2*sqrt(w) steps on the interval of width w = Q/SmoothPart.
+	w := new(big.Int).Div(g.Q, a.SmoothPart)
+	if w.Cmp(Big1) > 0 {
+		a.KangarooWork = 1 + log2(w)/2
+	}
+
+	return a
+}
+
+func (a *GroupAudit) addFactor(f dhgroup.Factor, bound uint32) {
+	if !f.Prime.IsUint64() || f.Prime.Uint64() > uint64(bound) {
+		a.LargeFactors = append(a.LargeFactors, f)
+		return
+	}
+	r := new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exp)), nil)
+	a.SmallFactors = append(a.SmallFactors, f)
+	a.SmoothPart.Mul(a.SmoothPart, r)
+	a.OracleQueries.Add(a.OracleQueries, r)
+}
+
+// Report writes a human-readable report to w.
+func (a *GroupAudit) Report(w io.Writer) {
+	fmt.Fprintf(w, "Group: %s\n", a.Name)
+	fmt.Fprintf(w, "  p is prime:             %t\n", a.PIsPrime)
+	fmt.Fprintf(w, "  Q is prime:             %t\n", a.QIsPrime)
+	fmt.Fprintf(w, "  g has order Q:          %t\n", a.GeneratorHasOrderQ)
+	fmt.Fprintf(w, "  cofactor bits:          %d\n", a.Cofactor.BitLen())
+	fmt.Fprintf(w, "  small factors:         ")
+	for _, f := range a.SmallFactors {
+		if f.Exp > 1 {
+			fmt.Fprintf(w, " %d^%d", f.Prime, f.Exp)
+		} else {
+			fmt.Fprintf(w, " %d", f.Prime)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  large factors (bits):  ")
+	for _, f := range a.LargeFactors {
+		fmt.Fprintf(w, " %d", f.Prime.BitLen())
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  smooth part bits:       %d\n", a.SmoothPart.BitLen())
+	fmt.Fprintf(w, "  unfactored bits:        %d\n", a.Unfactored.BitLen())
+	fmt.Fprintf(w, "  leakable key bits:      %.1f\n", a.LeakableBits)
+	fmt.Fprintf(w, "  oracle queries:         %d\n", a.OracleQueries)
+	fmt.Fprintf(w, "  kangaroo work (log2):   %.1f\n", a.KangarooWork)
+}
+
+// log2 returns the binary logarithm of a positive n.
+func log2(n *big.Int) float64 {
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(n).MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
@@ -0,0 +1,51 @@
+package dhpals
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestAuditGroup(t *testing.T) {
+	g := dhgroup.MODP512V57().DHParams()
+	a := AuditGroup(g, 1<<16)
+
+	if !a.PIsPrime || !a.QIsPrime || !a.GeneratorHasOrderQ {
+		t.Fatalf("%s: wrong primality checks for %s", t.Name(), g.Name)
+	}
+
+	// All factors from docs/small_subgroup_attack.txt below 2^16.
+	wantSmooth, _ := new(big.Int).SetString("158309698371052930674404681896270997115487590", 10)
+	if a.SmoothPart.Cmp(wantSmooth) != 0 {
+		t.Errorf("%s: smooth part: got %d, want %d", t.Name(), a.SmoothPart, wantSmooth)
+	}
+	if len(a.LargeFactors) != 1 || a.LargeFactors[0].Prime.Cmp(big.NewInt(96142199)) != 0 {
+		t.Errorf("%s: wrong large factors", t.Name())
+	}
+
+	// The smooth part exceeds Q, so the whole key leaks and no kangaroo is needed.
+	if a.LeakableBits != log2(g.Q) || a.KangarooWork != 0 {
+		t.Errorf("%s: got %.2f leakable bits and %.2f kangaroo work", t.Name(), a.LeakableBits, a.KangarooWork)
+	}
+
+	n := new(big.Int).Mul(a.SmoothPart, a.Unfactored)
+	for _, f := range a.LargeFactors {
+		n.Mul(n, f.Prime)
+	}
+	if n.Cmp(a.Cofactor) != 0 {
+		t.Errorf("%s: factors do not multiply to the cofactor", t.Name())
+	}
+}
+
+func TestAuditSafePrimeGroup(t *testing.T) {
+	g := dhgroup.MODP2048().DHParams()
+	a := AuditGroup(g, 1<<16)
+
+	if a.LeakableBits != 1 || a.OracleQueries.Cmp(Big2) != 0 {
+		t.Errorf("%s: got %.2f leakable bits, want 1", t.Name(), a.LeakableBits)
+	}
+	if a.KangarooWork < 1000 {
+		t.Errorf("%s: kangaroo work is too small: %.2f", t.Name(), a.KangarooWork)
+	}
+}
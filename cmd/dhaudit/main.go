@@ -0,0 +1,60 @@
+// Command dhaudit analyses DH group parameters against small-subgroup
+// confinement and Pollard's kangaroo attacks.
+//
+// Usage:
+//
+//	dhaudit [-bound n] [-id group-id] [params.pem ...]
+//
+// The parameters are read from PEM files with PKCS#3 or X9.42 DH parameters
+// (e.g. produced by openssl dhparam) or taken from the dhgroup package by ID.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dnkolegov/dhpals"
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func main() {
+	bound := flag.Uint("bound", 1<<20, "trial division bound")
+	id := flag.Int("id", -1, "dhgroup group ID")
+	flag.Parse()
+
+	var groups []*dhgroup.GroupParams
+	if *id >= 0 {
+		g, err := dhgroup.GroupForGroupID(dhgroup.ID(*id))
+		if err != nil {
+			fatal(err)
+		}
+		groups = append(groups, g.DHParams())
+	}
+	for _, name := range flag.Args() {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			fatal(err)
+		}
+		g, err := dhgroup.ParseParametersPEM(data)
+		if err != nil {
+			fatal(fmt.Errorf("%s: %v", name, err))
+		}
+		g.Name = name
+		groups = append(groups, g)
+	}
+	if len(groups) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, g := range groups {
+		dhpals.AuditGroup(g, uint32(*bound)).Report(os.Stdout)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "dhaudit:", err)
+	os.Exit(1)
+}
@@ -1,7 +1,11 @@
 package dhpals
 
 import (
+	"context"
+	"crypto/rand"
 	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
 )
 
 func runDHSmallSubgroupAttack(p, cofactor *big.Int, dh func(*big.Int) []byte) (priv *big.Int) {
@@ -19,3 +23,76 @@ func runDHKangarooAttack(p, g, q, cofactor *big.Int, dh func(*big.Int) []byte, g
 	panic("not implemented")
 	return nil
 }
+
+// runDHShortExponentAttack recovers a private key of at most bits bits
+// from the public key using the parallel kangaroo algorithm.
+func runDHShortExponentAttack(p, g *big.Int, bits int, getPublicKey func() *big.Int) (priv *big.Int) {
+	b := new(big.Int).Lsh(Big1, uint(bits))
+	priv, _, err := catchKangarooParallel(context.Background(), p, g, getPublicKey(), Big1, b, kangarooConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// runRaccoonAttack recovers the shared secret of the observed handshake using the
+// timing leak of the Raccoon attack (https://raccoon-attack.com/).
+//
+// The attacker sends client*g^r to the server. The server computes the secret
+// s*server^r, and if it has a leading zero byte, it is processed faster.
+// Each such query gives an instance of the hidden number problem
+// s*t mod p < 2^(8*(len-1)), where t = server^r is known, which is solved with a lattice.
+func runRaccoonAttack(group dhgroup.DHScheme, dh func(*big.Int) ([]byte, int), getPublicKeys func() (*big.Int, *big.Int)) (secret *big.Int) {
+	params := group.DHParams()
+	p, g := params.P, params.G
+	server, client := getPublicKeys()
+
+	n := (group.DHLen() + 7) / 8
+	bound := new(big.Int).Lsh(Big1, uint(8*(n-1)))
+
+	// Every sample leaks about log2(p/bound) bits; collect enough of them
+	// to determine the secret with a margin.
+	leaked := p.BitLen() - bound.BitLen() + 1
+	m := (3*p.BitLen())/(2*leaked) + 4
+
+	var samples []*big.Int
+	for len(samples) < m {
+		r, err := rand.Int(rand.Reader, params.Q)
+		if err != nil {
+			panic(err)
+		}
+		x := new(big.Int).Exp(g, r, p)
+		x.Mul(x, client)
+		x.Mod(x, p)
+
+		if _, elapsed := dh(x); elapsed < n {
+			samples = append(samples, new(big.Int).Exp(server, r, p))
+		}
+	}
+
+	secret, err := solveHNP(p, bound, samples)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// runLogjamAttack recovers the private keys of many parties that use the same group
+// from their public keys. The expensive index calculus precomputation is done once
+// (or loaded from the database at path); then every key only needs an individual logarithm.
+func runLogjamAttack(group dhgroup.DHScheme, path string, getPublicKeys []func() *big.Int) (privs []*big.Int) {
+	params := group.DHParams()
+	fb, err := loadFactorBase(path, params.P, params.G, params.Q)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, getPublicKey := range getPublicKeys {
+		x, err := fb.log(getPublicKey())
+		if err != nil {
+			panic(err)
+		}
+		privs = append(privs, x)
+	}
+	return
+}
@@ -9,11 +9,10 @@ import (
 )
 
 func TestSmallSubgroupAttack(t *testing.T) {
-	p, _ := new(big.Int).SetString("7199773997391911030609999317773941274322764333428698921736339643928346453700085358802973900485592910475480089726140708102474957429903531369589969318716771", 10)
-	g, _ := new(big.Int).SetString("4565356397095740655436854503483826832136106141639563487732438195343690437606117828318042418238184896212352329118608100083187535033402010599512641674644143", 10)
-	q, _ := new(big.Int).SetString("236234353446506858198510045061214171961", 10)
+	group := dhgroup.MODP512V57().DHParams()
+	p, g, q := group.P, group.G, group.Q
 	// (p-1) = q*cofactor.
-	cofactor, _ := new(big.Int).SetString("30477252323177606811760882179058908038824640750610513771646768011063128035873508507547741559514324673960576895059570", 10)
+	cofactor := group.Cofactor()
 
 	// check that g^q = 1 mod p
 	e := new(big.Int).Exp(g, q, p)
@@ -29,6 +28,60 @@ func TestSmallSubgroupAttack(t *testing.T) {
 	if !isKeyCorrect(privateKey.Bytes()) {
 		t.Fatalf("%s: wrong private key was found in the sugbroup attack", t.Name())
 	}
+
+	for _, v := range validationPolicyTests {
+		t.Run(v.policy.String(), func(t *testing.T) {
+			oracle, isKeyCorrect, _ := newValidatingDHOracle(dhgroup.ModP512v57, v.policy)
+			privateKey, err := runUnderPolicy(func() *big.Int {
+				return runDHSmallSubgroupAttack(p, cofactor, oracle)
+			})
+			checkPolicyResult(t, v.stopped, err, privateKey, isKeyCorrect)
+		})
+	}
+}
+
+// validationPolicyTests lists which validation policies stop the small-subgroup
+// and kangaroo attacks. RangeCheck rejects only 1 and p-1, so it stops the attacks
+// only if they use the element of order 2.
+var validationPolicyTests = []struct {
+	policy  dhgroup.ValidationPolicy
+	stopped bool
+}{
+	{dhgroup.NoValidation, false},
+	{dhgroup.RangeCheck, false},
+	{dhgroup.SubgroupCheck, true},
+	{dhgroup.CofactorClearing, true},
+}
+
+// runUnderPolicy runs the attack and returns the validation error if the oracle
+// rejected a public key. Other panics are propagated.
+func runUnderPolicy(attack func() *big.Int) (x *big.Int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r {
+			case dhgroup.ErrPublicKeyOutOfRange, dhgroup.ErrPublicKeyNotInSubgroup, dhgroup.ErrSmallOrderSharedSecret:
+				err = r.(error)
+			default:
+				panic(r)
+			}
+		}
+	}()
+	return attack(), nil
+}
+
+func checkPolicyResult(t *testing.T, stopped bool, err error, x *big.Int, isKeyCorrect func([]byte) bool) {
+	if stopped {
+		if err == nil && isKeyCorrect(x.Bytes()) {
+			t.Errorf("%s: the attack was not stopped", t.Name())
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("%s: the oracle rejected a public key: %v", t.Name(), err)
+	}
+	if !isKeyCorrect(x.Bytes()) {
+		t.Errorf("%s: wrong private key was found", t.Name())
+	}
 }
 
 type kangarooTest struct {
@@ -63,11 +116,10 @@ func TestKangarooAlgorithm(t *testing.T) {
 }
 
 func TestKangarooAttack(t *testing.T) {
-	p, _ := new(big.Int).SetString("11470374874925275658116663507232161402086650258453896274534991676898999262641581519101074740642369848233294239851519212341844337347119899874391456329785623", 10)
-	g, _ := new(big.Int).SetString("622952335333961296978159266084741085889881358738459939978290179936063635566740258555167783009058567397963466103140082647486611657350811560630587013183357", 10)
-	q, _ := new(big.Int).SetString("335062023296420808191071248367701059461", 10)
+	group := dhgroup.MODP512V58().DHParams()
+	p, g, q := group.P, group.G, group.Q
 	// p-1 = q*cofactor
-	cofactor, _ := new(big.Int).SetString("34233586850807404623475048381328686211071196701374230492615844865929237417097514638999377942356150481334217896204702", 10)
+	cofactor := group.Cofactor()
 
 	oracle, isKeyCorrect, getPublicKey := newDHOracle(dhgroup.ModP512v58)
 
@@ -77,4 +129,51 @@ func TestKangarooAttack(t *testing.T) {
 		t.Fatalf("%s: wrong private key was found in the sugbroup attack", t.Name())
 	}
 	fmt.Printf("%s: Found key: %d\n", t.Name(), x)
+
+	for _, v := range validationPolicyTests {
+		t.Run(v.policy.String(), func(t *testing.T) {
+			oracle, isKeyCorrect, getPublicKey := newValidatingDHOracle(dhgroup.ModP512v58, v.policy)
+			x, err := runUnderPolicy(func() *big.Int {
+				return runDHKangarooAttack(p, g, q, cofactor, oracle, getPublicKey)
+			})
+			checkPolicyResult(t, v.stopped, err, x, isKeyCorrect)
+		})
+	}
+}
+
+func TestShortExponentAttack(t *testing.T) {
+	for _, id := range []dhgroup.ID{dhgroup.ModP1536, dhgroup.ModP2048} {
+		group, _ := dhgroup.GroupForGroupID(id)
+		p, g := group.DHParams().P, group.DHParams().G
+
+		_, isKeyCorrect, getPublicKey := newShortExponentDHOracle(id, 32)
+
+		x := runDHShortExponentAttack(p, g, 32, getPublicKey)
+
+		if !isKeyCorrect(x.Bytes()) {
+			t.Fatalf("%s: wrong private key was found in the short exponent attack for %s", t.Name(), group.DHName())
+		}
+		t.Logf("%s: Found key: %d\n", t.Name(), x)
+	}
+}
+
+func TestRaccoonAttack(t *testing.T) {
+	// The attack is demonstrated on a 128-bit prime field to keep the lattice small.
+	p, _ := new(big.Int).SetString("233970423115425145524320034830162017933", 10)
+	group := &dhgroup.GroupParams{
+		P:       p,
+		G:       Big2,
+		Q:       new(big.Int).Sub(p, Big1),
+		Name:    "Raccoon-128",
+		BitSize: 128,
+	}
+
+	dh, getPublicKeys, isSecretCorrect := newRaccoonOracle(group)
+
+	secret := runRaccoonAttack(group, dh, getPublicKeys)
+
+	if !isSecretCorrect(dhgroup.SharedSecretBytes(group, secret)) {
+		t.Fatalf("%s: wrong shared secret was found in the Raccoon attack", t.Name())
+	}
+	t.Logf("%s: Found secret: %d\n", t.Name(), secret)
 }
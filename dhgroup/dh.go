@@ -2,6 +2,7 @@
 package dhgroup
 
 import (
+	"crypto/rand"
 	"fmt"
 	"io"
 	"math/big"
@@ -17,6 +18,27 @@ const (
 	ModP768    ID = 101
 	ModP1536   ID = 102
 	ModP2048   ID = 103
+
+	// RFC 5114 groups use their IKE transform IDs.
+	ModP1024s160 ID = 22
+	ModP2048s224 ID = 23
+	ModP2048s256 ID = 24
+
+	// RFC 7919 groups use their TLS NamedGroup code points.
+	Ffdhe2048 ID = 256
+	Ffdhe3072 ID = 257
+	Ffdhe4096 ID = 258
+	Ffdhe6144 ID = 259
+	Ffdhe8192 ID = 260
+
+	// RFC 5054 groups have no registered code points.
+	Srp1024 ID = 5054
+	Srp1536 ID = 5055
+	Srp2048 ID = 5056
+	Srp3072 ID = 5057
+	Srp4096 ID = 5058
+	Srp6144 ID = 5059
+	Srp8192 ID = 5060
 )
 
 type DHKey struct {
@@ -51,6 +73,23 @@ type GroupParams struct {
 	Q       *big.Int
 	Name    string
 	BitSize int
+
+	// SRPGenerator is the generator g used by SRP if it differs from G.
+	// The RFC 5054 groups set it to their non-residue g, and G = g^2.
+	SRPGenerator *big.Int
+
+	// factors is the known part of the factorization of (p-1)/Q.
+	factors []Factor
+
+	// base is the lazily built fixed-base table of G, see ScalarBaseExp.
+	base *fixedBase
+
+	// Validation is the policy applied to peer public keys in DH.
+	Validation ValidationPolicy
+
+	// ExponentBits is the bit length of private keys generated by GenerateKey.
+	// Zero means that private keys are chosen from [1, Q).
+	ExponentBits int
 }
 
 func (g *GroupParams) DHParams() *GroupParams {
@@ -58,16 +97,78 @@ func (g *GroupParams) DHParams() *GroupParams {
 }
 
 func (g GroupParams) GenerateKey(rng io.Reader) (DHKey, error) {
-	panic("Not implemented")
+	if rng == nil {
+		rng = rand.Reader
+	}
+
+	// The private key is chosen uniformly from [1, Q) or from [1, 2^ExponentBits)
+	// for short exponents.
+	max := new(big.Int).Sub(g.Q, big.NewInt(1))
+	if g.ExponentBits > 0 && g.ExponentBits < g.Q.BitLen() {
+		max.Lsh(big.NewInt(1), uint(g.ExponentBits))
+		max.Sub(max, big.NewInt(1))
+	}
+	private, err := rand.Int(rng, max)
+	if err != nil {
+		return DHKey{}, err
+	}
+	private.Add(private, big.NewInt(1))
+
 	return DHKey{
-		Private: nil,
-		Public:  nil,
+		Private: private,
+		Public:  g.ScalarBaseExp(private),
 	}, nil
 }
 
+// DH returns public^private mod p after the public key is checked with the
+// validation policy of the group. With CofactorClearing the result is
+// public^(private*h), where h is the cofactor, and it does not match the result
+// of a peer that uses another policy.
 func (g GroupParams) DH(private, public *big.Int) (*big.Int, error) {
-	panic("not implemented")
-	return nil, nil
+	public, err := g.validate(public)
+	if err != nil {
+		return nil, err
+	}
+	zz := new(big.Int).Exp(public, private, g.P)
+	if g.Validation == CofactorClearing && zz.Cmp(big.NewInt(1)) == 0 {
+		return nil, ErrSmallOrderSharedSecret
+	}
+	return zz, nil
+}
+
+// WithShortExponent returns a copy of the group that generates private keys
+// of at most bits bits. Many implementations use such short exponents
+// (e.g. 160-256 bits) in large groups to speed up DH.
+func WithShortExponent(group DHScheme, bits int) DHScheme {
+	g := *group.DHParams()
+	g.ExponentBits = bits
+	return &g
+}
+
+// Factor is a prime factor of an integer and its multiplicity.
+type Factor struct {
+	Prime *big.Int
+	Exp   int
+}
+
+// Cofactor returns the cofactor (p-1)/Q of the subgroup generated by G.
+func (g GroupParams) Cofactor() *big.Int {
+	h := new(big.Int).Sub(g.P, big.NewInt(1))
+	return h.Div(h, g.Q)
+}
+
+// CofactorFactors returns the known prime factors of the cofactor in ascending
+// order and the part of the cofactor that is left unfactored (1 if the
+// factorization is complete).
+func (g GroupParams) CofactorFactors() (factors []Factor, rest *big.Int) {
+	rest = g.Cofactor()
+	for _, f := range g.factors {
+		for i := 0; i < f.Exp; i++ {
+			rest.Div(rest, f.Prime)
+		}
+		factors = append(factors, Factor{new(big.Int).Set(f.Prime), f.Exp})
+	}
+	return
 }
 
 func (g GroupParams) DHLen() int {
@@ -92,6 +193,28 @@ func initAll() {
 	initMODP2048()
 	initMODP512V57()
 	initMODP512V58()
+	initMODP1024S160()
+	initMODP2048S224()
+	initMODP2048S256()
+	initFFDHE2048()
+	initFFDHE3072()
+	initFFDHE4096()
+	initFFDHE6144()
+	initFFDHE8192()
+	initSRP1024()
+	initSRP1536()
+	initSRP2048()
+	initSRP3072()
+	initSRP4096()
+	initSRP6144()
+	initSRP8192()
+
+	for _, g := range []*GroupParams{modp768, modp1536, modp2048, modp512v57, modp512v58,
+		modp1024s160, modp2048s224, modp2048s256,
+		ffdhe2048, ffdhe3072, ffdhe4096, ffdhe6144, ffdhe8192,
+		srp1024, srp1536, srp2048, srp3072, srp4096, srp6144, srp8192} {
+		g.base = newFixedBase(g.G, g.P, g.Q.BitLen())
+	}
 }
 
 var initonce sync.Once
@@ -104,7 +227,9 @@ func initMODP768() {
 		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
 		"E485B576625E7EC6F44C42E9A63A3620FFFFFFFFFFFFFFFF")
 	modp768.G = big.NewInt(2)
-	modp768.Q = new(big.Int).Sub(modp768.P, big.NewInt(1))
+	// p is a safe prime, so g = 2 generates the subgroup of order (p-1)/2.
+	modp768.Q = new(big.Int).Rsh(modp768.P, 1)
+	modp768.factors = []Factor{{big.NewInt(2), 1}}
 	modp768.BitSize = 768
 }
 
@@ -120,7 +245,9 @@ func initMODP1536() {
 		"bb9ed529077096966d670c354e4abc9804f1746c08ca237327fff" +
 		"fffffffffffff")
 	modp1536.G = big.NewInt(2)
-	modp1536.Q = new(big.Int).Sub(modp1536.P, big.NewInt(1))
+	// p is a safe prime, so g = 2 generates the subgroup of order (p-1)/2.
+	modp1536.Q = new(big.Int).Rsh(modp1536.P, 1)
+	modp1536.factors = []Factor{{big.NewInt(2), 1}}
 	modp1536.BitSize = 1536
 }
 
@@ -138,7 +265,9 @@ func initMODP2048() {
 		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
 		"15728E5A8AACAA68FFFFFFFFFFFFFFFF")
 	modp2048.G = big.NewInt(2)
-	modp2048.Q = new(big.Int).Sub(modp2048.P, big.NewInt(1))
+	// p is a safe prime, so g = 2 generates the subgroup of order (p-1)/2.
+	modp2048.Q = new(big.Int).Rsh(modp2048.P, 1)
+	modp2048.factors = []Factor{{big.NewInt(2), 1}}
 	modp2048.BitSize = 2048
 }
 
@@ -149,6 +278,22 @@ func initMODP512V57() {
 	modp512v57.G = bigFromBase10("4565356397095740655436854503483826832136106141639563487732438195343690437606117828318042418238184896212352329118608100083187535033402010599512641674644143")
 	modp512v57.Q = bigFromBase10("236234353446506858198510045061214171961")
 	modp512v57.BitSize = 512
+	modp512v57.factors = []Factor{
+		{big.NewInt(2), 1},
+		{big.NewInt(3), 2},
+		{big.NewInt(5), 1},
+		{big.NewInt(109), 1},
+		{big.NewInt(7963), 1},
+		{big.NewInt(8539), 1},
+		{big.NewInt(20641), 1},
+		{big.NewInt(38833), 1},
+		{big.NewInt(39341), 1},
+		{big.NewInt(46337), 1},
+		{big.NewInt(51977), 1},
+		{big.NewInt(54319), 1},
+		{big.NewInt(57529), 1},
+		{big.NewInt(96142199), 1},
+	}
 }
 
 func initMODP512V58() {
@@ -157,6 +302,16 @@ func initMODP512V58() {
 	modp512v58.G = bigFromBase10("622952335333961296978159266084741085889881358738459939978290179936063635566740258555167783009058567397963466103140082647486611657350811560630587013183357")
 	modp512v58.Q = bigFromBase10("335062023296420808191071248367701059461")
 	modp512v58.BitSize = 512
+	modp512v58.factors = []Factor{
+		{big.NewInt(2), 1},
+		{big.NewInt(12457), 1},
+		{big.NewInt(14741), 1},
+		{big.NewInt(18061), 1},
+		{big.NewInt(31193), 1},
+		{big.NewInt(33941), 1},
+		{big.NewInt(63803), 1},
+		{bigFromBase10("76404216680248147890725465466734243970853371218438495046025185507551527833728478710665337"), 1},
+	}
 }
 
 func MODP768() DHScheme {
@@ -184,6 +339,21 @@ func MODP512V58() DHScheme {
 	return modp512v58
 }
 
+// SharedSecretBytes encodes the shared secret as a big-endian byte string of the
+// fixed length (DHLen()+7)/8, preserving leading zeros as RFC 2631 and TLS 1.3 require.
+// Stripping the leading zeros (as big.Int.Bytes does) makes the key derivation time
+// depend on the secret, which is exploited by the Raccoon attack.
+func SharedSecretBytes(group DHScheme, zz *big.Int) []byte {
+	b := zz.Bytes()
+	n := (group.DHLen() + 7) / 8
+	if len(b) >= n {
+		return b
+	}
+	out := make([]byte, n)
+	copy(out[n-len(b):], b)
+	return out
+}
+
 func bigFromBase10(s string) *big.Int {
 	n, _ := new(big.Int).SetString(s, 10)
 	return n
@@ -194,18 +364,58 @@ func bigFromBase16(s string) *big.Int {
 	return n
 }
 
+// GroupForGroupID returns the group with the given ID.
+// Custom groups can be added with RegisterGroup.
 func GroupForGroupID(groupID ID) (group DHScheme, err error) {
+	group, err = builtinGroupForGroupID(groupID)
+	if err != nil {
+		return registeredGroup(groupID)
+	}
+	return
+}
+
+func builtinGroupForGroupID(groupID ID) (group DHScheme, err error) {
 	switch groupID {
 	case ModP512v57:
 		group = MODP512V57()
 	case ModP512v58:
 		group = MODP512V58()
+	case ModP1024s160:
+		group = MODP1024S160()
+	case ModP2048s224:
+		group = MODP2048S224()
+	case ModP2048s256:
+		group = MODP2048S256()
 	case ModP768:
 		group = MODP768()
 	case ModP1536:
 		group = MODP1536()
 	case ModP2048:
 		group = MODP2048()
+	case Ffdhe2048:
+		group = FFDHE2048()
+	case Ffdhe3072:
+		group = FFDHE3072()
+	case Ffdhe4096:
+		group = FFDHE4096()
+	case Ffdhe6144:
+		group = FFDHE6144()
+	case Ffdhe8192:
+		group = FFDHE8192()
+	case Srp1024:
+		group = SRP1024()
+	case Srp1536:
+		group = SRP1536()
+	case Srp2048:
+		group = SRP2048()
+	case Srp3072:
+		group = SRP3072()
+	case Srp4096:
+		group = SRP4096()
+	case Srp6144:
+		group = SRP6144()
+	case Srp8192:
+		group = SRP8192()
 	default:
 		group = nil
 		err = fmt.Errorf("dhgroup: Unknown or unsupported group id: %d", groupID)
@@ -2,12 +2,17 @@ package dhgroup
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
+	_ "crypto/sha1"
+	"crypto/sha256"
+	"math/big"
+	mrand "math/rand"
 	"testing"
 )
 
 func TestDH(t *testing.T) {
-	for _, v := range []ID{ModP512v57, ModP512v58, ModP768, ModP1536, ModP2048} {
+	for _, v := range []ID{ModP512v57, ModP512v58, ModP768, ModP1536, ModP2048, ModP1024s160, ModP2048s224, ModP2048s256} {
 		g, _ := GroupForGroupID(v)
 
 		// Alice generates a key pair.
@@ -43,3 +48,567 @@ func TestDH(t *testing.T) {
 		}
 	}
 }
+
+func TestValidationPolicies(t *testing.T) {
+	g := MODP512V57().DHParams()
+	pm1 := new(big.Int).Sub(g.P, big.NewInt(1))
+
+	// h has order 109 which divides (p-1)/q.
+	r := big.NewInt(109)
+	e := new(big.Int).Div(pm1, r)
+	var h *big.Int
+	for b := int64(2); ; b++ {
+		h = new(big.Int).Exp(big.NewInt(b), e, g.P)
+		if h.Cmp(big.NewInt(1)) != 0 {
+			break
+		}
+	}
+
+	key, _ := g.GenerateKey(rand.Reader)
+
+	var validationTests = []struct {
+		policy ValidationPolicy
+		public *big.Int
+		err    error
+	}{
+		{NoValidation, big.NewInt(1), nil},
+		{NoValidation, h, nil},
+		{RangeCheck, big.NewInt(1), ErrPublicKeyOutOfRange},
+		{RangeCheck, pm1, ErrPublicKeyOutOfRange},
+		{RangeCheck, h, nil},
+		{SubgroupCheck, pm1, ErrPublicKeyOutOfRange},
+		{SubgroupCheck, h, ErrPublicKeyNotInSubgroup},
+		{SubgroupCheck, key.Public, nil},
+		{CofactorClearing, h, ErrSmallOrderSharedSecret},
+		{CofactorClearing, key.Public, nil},
+	}
+
+	for i, v := range validationTests {
+		s := WithValidation(g, v.policy)
+		_, err := s.DH(key.Private, v.public)
+		if err != v.err {
+			t.Errorf("%s - #%d: policy %s: got %v, want %v", t.Name(), i, v.policy, err, v.err)
+		}
+	}
+
+	if g.Validation != NoValidation {
+		t.Errorf("%s: WithValidation modified the original group", t.Name())
+	}
+}
+
+func TestCofactorClearingAgreement(t *testing.T) {
+	g := WithValidation(MODP512V57(), CofactorClearing)
+	a, _ := g.GenerateKey(rand.Reader)
+	b, _ := g.GenerateKey(rand.Reader)
+
+	zza, err := g.DH(a.Private, b.Public)
+	if err != nil {
+		t.Fatalf("%s: Alice DH function failed: %v", t.Name(), err)
+	}
+	zzb, err := g.DH(b.Private, a.Public)
+	if err != nil {
+		t.Fatalf("%s: Bob DH function failed: %v", t.Name(), err)
+	}
+	if zza.Cmp(zzb) != 0 {
+		t.Errorf("%s: Alice and Bob key agreement failed", t.Name())
+	}
+
+	// The shared secret is g^(abh), so a peer without cofactor clearing gets a different one.
+	plain := MODP512V57()
+	zzp, err := plain.DH(b.Private, a.Public)
+	if err != nil {
+		t.Fatalf("%s: Bob DH function failed: %v", t.Name(), err)
+	}
+	if zzp.Cmp(zza) == 0 {
+		t.Errorf("%s: the policy did not change the shared secret", t.Name())
+	}
+	h := g.DHParams().Cofactor()
+	if zzp.Exp(zzp, h, g.DHParams().P).Cmp(zza) != 0 {
+		t.Errorf("%s: the shared secret is not g^(abh)", t.Name())
+	}
+}
+
+func TestFFDHEGroups(t *testing.T) {
+	for _, v := range []ID{Ffdhe2048, Ffdhe3072, Ffdhe4096, Ffdhe6144, Ffdhe8192} {
+		g, err := GroupForGroupID(v)
+		if err != nil {
+			t.Fatalf("%s: unknown group %d", t.Name(), v)
+		}
+		params := g.DHParams()
+
+		if params.P.BitLen() != g.DHLen() {
+			t.Errorf("%s: wrong bit size of %s", t.Name(), g.DHName())
+		}
+
+		if !params.Q.ProbablyPrime(1) {
+			t.Errorf("%s: Q is not prime for %s", t.Name(), g.DHName())
+		}
+
+		pm1 := new(big.Int).Sub(params.P, big.NewInt(1))
+		if pm1.Cmp(new(big.Int).Lsh(params.Q, 1)) != 0 {
+			t.Errorf("%s: p-1 != 2*Q for %s", t.Name(), g.DHName())
+		}
+
+		if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("%s: g^Q != 1 mod p for %s", t.Name(), g.DHName())
+		}
+	}
+}
+
+func TestRFC5114Groups(t *testing.T) {
+	for _, v := range []ID{ModP1024s160, ModP2048s224, ModP2048s256} {
+		g, err := GroupForGroupID(v)
+		if err != nil {
+			t.Fatalf("%s: unknown group %d", t.Name(), v)
+		}
+		params := g.DHParams()
+
+		if !params.Q.ProbablyPrime(20) {
+			t.Errorf("%s: Q is not prime for %s", t.Name(), g.DHName())
+		}
+
+		if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("%s: g^Q != 1 mod p for %s", t.Name(), g.DHName())
+		}
+
+		cofactor := params.Cofactor()
+		factors, rest := params.CofactorFactors()
+		if len(factors) == 0 {
+			t.Errorf("%s: no cofactor factors for %s", t.Name(), g.DHName())
+		}
+
+		n := new(big.Int).Set(rest)
+		for _, f := range factors {
+			if !f.Prime.ProbablyPrime(20) {
+				t.Errorf("%s: factor %d is not prime for %s", t.Name(), f.Prime, g.DHName())
+			}
+			n.Mul(n, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exp)), nil))
+		}
+		if n.Cmp(cofactor) != 0 {
+			t.Errorf("%s: wrong cofactor factorization for %s", t.Name(), g.DHName())
+		}
+
+		pm1 := new(big.Int).Mul(cofactor, params.Q)
+		pm1.Add(pm1, big.NewInt(1))
+		if pm1.Cmp(params.P) != 0 {
+			t.Errorf("%s: p != Q*cofactor + 1 for %s", t.Name(), g.DHName())
+		}
+	}
+}
+
+func TestGroupStructure(t *testing.T) {
+	for _, v := range []ID{ModP512v57, ModP512v58, ModP768, ModP1536, ModP2048, ModP1024s160, ModP2048s224, ModP2048s256, Ffdhe2048, Srp1024, Srp2048} {
+		g, _ := GroupForGroupID(v)
+		params := g.DHParams()
+
+		cofactor := params.Cofactor()
+		n := new(big.Int).Mul(cofactor, params.Q)
+		n.Add(n, big.NewInt(1))
+		if n.Cmp(params.P) != 0 {
+			t.Errorf("%s: p != Q*cofactor + 1 for %s", t.Name(), g.DHName())
+		}
+
+		factors, rest := params.CofactorFactors()
+		n.Set(rest)
+		for _, f := range factors {
+			n.Mul(n, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exp)), nil))
+		}
+		if n.Cmp(cofactor) != 0 {
+			t.Errorf("%s: wrong cofactor factorization for %s", t.Name(), g.DHName())
+		}
+
+		if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("%s: g^Q != 1 mod p for %s", t.Name(), g.DHName())
+		}
+
+		for i := 0; i < 10; i++ {
+			key, err := g.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatalf("%s: key generation failed for %s", t.Name(), g.DHName())
+			}
+			if key.Private.Sign() <= 0 || key.Private.Cmp(params.Q) >= 0 {
+				t.Errorf("%s: private key out of [1, Q) for %s", t.Name(), g.DHName())
+			}
+		}
+	}
+}
+
+func TestGenerateWeakGroup(t *testing.T) {
+	cfg := WeakGroupConfig{QBits: 64, SmoothnessBound: 1 << 16, SmallFactors: 12}
+
+	g, factors, err := GenerateWeakGroup(mrand.New(mrand.NewSource(1)), cfg)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+
+	if !g.P.ProbablyPrime(20) || !g.Q.ProbablyPrime(20) || g.Q.BitLen() != cfg.QBits {
+		t.Fatalf("%s: wrong group parameters", t.Name())
+	}
+	if new(big.Int).Exp(g.G, g.Q, g.P).Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("%s: g^Q != 1 mod p", t.Name())
+	}
+	if len(factors) != cfg.SmallFactors+1 {
+		t.Errorf("%s: got %d factors, want %d", t.Name(), len(factors), cfg.SmallFactors+1)
+	}
+
+	n := big.NewInt(1)
+	for _, f := range factors {
+		if f.Prime.Cmp(big.NewInt(cfg.SmoothnessBound)) >= 0 {
+			t.Errorf("%s: factor %d is not below the smoothness bound", t.Name(), f.Prime)
+		}
+		n.Mul(n, f.Prime)
+	}
+	if n.Cmp(g.Cofactor()) != 0 {
+		t.Errorf("%s: wrong cofactor factorization", t.Name())
+	}
+
+	// The same seed gives the same group.
+	g1, _, err := GenerateWeakGroup(mrand.New(mrand.NewSource(1)), cfg)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if g.P.Cmp(g1.P) != 0 || g.G.Cmp(g1.G) != 0 {
+		t.Errorf("%s: generation is not reproducible", t.Name())
+	}
+
+	if _, _, err := GenerateWeakGroup(nil, WeakGroupConfig{QBits: 32, SmoothnessBound: 8, SmallFactors: 5}); err == nil {
+		t.Errorf("%s: expected an error for too many small factors", t.Name())
+	}
+}
+
+func TestShortExponent(t *testing.T) {
+	g := WithShortExponent(MODP2048(), 160)
+	for i := 0; i < 100; i++ {
+		key, err := g.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("%s: key generation failed", t.Name())
+		}
+		if key.Private.Sign() <= 0 || key.Private.BitLen() > 160 {
+			t.Fatalf("%s: private key has %d bits, want at most 160", t.Name(), key.Private.BitLen())
+		}
+	}
+
+	if MODP2048().DHParams().ExponentBits != 0 {
+		t.Errorf("%s: WithShortExponent modified the original group", t.Name())
+	}
+}
+
+func TestSharedSecretBytes(t *testing.T) {
+	g := MODP512V57()
+	for _, v := range []*big.Int{big.NewInt(1), big.NewInt(0x1234), new(big.Int).Sub(g.DHParams().P, big.NewInt(1))} {
+		b := SharedSecretBytes(g, v)
+		if len(b) != 64 {
+			t.Errorf("%s: got %d bytes, want 64", t.Name(), len(b))
+		}
+		if new(big.Int).SetBytes(b).Cmp(v) != 0 {
+			t.Errorf("%s: wrong encoding of %d", t.Name(), v)
+		}
+	}
+}
+
+func TestScalarBaseExp(t *testing.T) {
+	for _, v := range []ID{ModP512v57, ModP1536, ModP2048s256, Ffdhe2048} {
+		g, _ := GroupForGroupID(v)
+		params := g.DHParams()
+
+		ks := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(31), big.NewInt(32),
+			new(big.Int).Sub(params.Q, big.NewInt(1)), new(big.Int).Lsh(params.Q, 2)}
+		for i := 0; i < 20; i++ {
+			k, _ := rand.Int(rand.Reader, params.Q)
+			ks = append(ks, k)
+		}
+
+		for _, k := range ks {
+			want := new(big.Int).Exp(params.G, k, params.P)
+			if got := params.ScalarBaseExp(k); got.Cmp(want) != 0 {
+				t.Fatalf("%s: wrong g^%d for %s", t.Name(), k, g.DHName())
+			}
+		}
+	}
+}
+
+func TestScalarBaseExpModifiedCopy(t *testing.T) {
+	params := MODP1536().DHParams()
+	k, _ := rand.Int(rand.Reader, params.Q)
+	params.ScalarBaseExp(k)
+
+	g := *params
+	g.G = big.NewInt(5)
+	want := new(big.Int).Exp(g.G, k, g.P)
+	if got := g.ScalarBaseExp(k); got.Cmp(want) != 0 {
+		t.Errorf("%s: the table of the original G is used for a copy with G = 5", t.Name())
+	}
+	if got := params.ScalarBaseExp(k); got.Cmp(new(big.Int).Exp(params.G, k, params.P)) != 0 {
+		t.Errorf("%s: wrong g^k for the original group", t.Name())
+	}
+
+	// A copy with another G that is used first does not build the table of the original group.
+	weak, _, err := GenerateWeakGroup(mrand.New(mrand.NewSource(3)), WeakGroupConfig{QBits: 64, SmoothnessBound: 1 << 12, SmallFactors: 4})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	c := *weak
+	c.G = new(big.Int).Exp(weak.G, big.NewInt(2), weak.P)
+	k = big.NewInt(12345)
+	if got := c.ScalarBaseExp(k); got.Cmp(new(big.Int).Exp(c.G, k, c.P)) != 0 {
+		t.Errorf("%s: wrong g^k for the copy", t.Name())
+	}
+	if weak.base.table != nil {
+		t.Errorf("%s: the copy built the table of the original group", t.Name())
+	}
+	if got := weak.ScalarBaseExp(k); got.Cmp(new(big.Int).Exp(weak.G, k, weak.P)) != 0 || weak.base.table == nil {
+		t.Errorf("%s: the table of the original group is not used", t.Name())
+	}
+}
+
+func TestScalarBaseExpLargeGroups(t *testing.T) {
+	for _, v := range []ID{Ffdhe3072, Ffdhe8192, Srp8192} {
+		g, _ := GroupForGroupID(v)
+		if g.DHParams().base != nil {
+			t.Errorf("%s: %s has a fixed-base table", t.Name(), g.DHName())
+		}
+	}
+}
+
+func BenchmarkScalarBaseExp(b *testing.B) {
+	g := MODP2048().DHParams()
+	k, _ := rand.Int(rand.Reader, g.Q)
+	g.ScalarBaseExp(k)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		g.ScalarBaseExp(k)
+	}
+}
+
+// BenchmarkScalarBaseExpFirstCall measures the first call including the construction
+// of the table. The allocations are the memory kept by the table.
+func BenchmarkScalarBaseExpFirstCall(b *testing.B) {
+	for _, v := range []ID{ModP1024s160, ModP2048, Ffdhe8192} {
+		group, _ := GroupForGroupID(v)
+		params := group.DHParams()
+		k, _ := rand.Int(rand.Reader, params.Q)
+		b.Run(params.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				g := *params
+				g.base = newFixedBase(g.G, g.P, g.Q.BitLen())
+				g.ScalarBaseExp(k)
+			}
+		})
+	}
+}
+
+func BenchmarkNaiveBaseExp(b *testing.B) {
+	g := MODP2048().DHParams()
+	k, _ := rand.Int(rand.Reader, g.Q)
+	for n := 0; n < b.N; n++ {
+		new(big.Int).Exp(g.G, k, g.P)
+	}
+}
+
+func BenchmarkGenerateKey(b *testing.B) {
+	g := MODP2048()
+	g.GenerateKey(rand.Reader)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		g.GenerateKey(rand.Reader)
+	}
+}
+
+func TestDSA(t *testing.T) {
+	for _, v := range []ID{ModP1024s160, ModP2048s224, ModP2048s256, ModP2048} {
+		group, _ := GroupForGroupID(v)
+		g := group.DHParams()
+		key, _ := g.GenerateKey(rand.Reader)
+
+		hash := sha256.Sum256([]byte("Hello, DSA!"))
+		r, s, err := g.Sign(key.Private, hash[:], RandomNonce(rand.Reader))
+		if err != nil {
+			t.Fatalf("%s: signing failed for %s: %v", t.Name(), g.DHName(), err)
+		}
+		if !g.Verify(key.Public, hash[:], r, s) {
+			t.Errorf("%s: valid signature was rejected for %s", t.Name(), g.DHName())
+		}
+
+		hash[0] ^= 1
+		if g.Verify(key.Public, hash[:], r, s) {
+			t.Errorf("%s: signature of another hash was accepted for %s", t.Name(), g.DHName())
+		}
+	}
+
+	g := MODP1024S160().DHParams()
+	key, _ := g.GenerateKey(rand.Reader)
+	_, _, err := g.Sign(key.Private, []byte{1}, func(q *big.Int) (*big.Int, error) {
+		return q, nil
+	})
+	if err != ErrInvalidNonce {
+		t.Errorf("%s: nonce out of range was accepted", t.Name())
+	}
+}
+
+func TestElGamal(t *testing.T) {
+	g := MODP2048S256()
+	p := g.DHParams().P
+	key, _ := g.GenerateKey(rand.Reader)
+
+	m1, _ := rand.Int(rand.Reader, p)
+	m2, _ := rand.Int(rand.Reader, p)
+	m1.Add(m1, big.NewInt(1)).Mod(m1, p)
+	m2.Add(m2, big.NewInt(1)).Mod(m2, p)
+
+	c1, err := ElGamalEncrypt(g, rand.Reader, key.Public, m1)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if m, _ := ElGamalDecrypt(g, key.Private, c1); m.Cmp(m1) != 0 {
+		t.Fatalf("%s: decryption failed", t.Name())
+	}
+
+	r1, _ := ElGamalRerandomize(g, rand.Reader, key.Public, c1)
+	if r1.C1.Cmp(c1.C1) == 0 {
+		t.Errorf("%s: re-randomization did not change the ciphertext", t.Name())
+	}
+	if m, _ := ElGamalDecrypt(g, key.Private, r1); m.Cmp(m1) != 0 {
+		t.Errorf("%s: re-randomized ciphertext decrypts to another message", t.Name())
+	}
+
+	c2, _ := ElGamalEncrypt(g, rand.Reader, key.Public, m2)
+	want := new(big.Int).Mul(m1, m2)
+	want.Mod(want, p)
+	if m, _ := ElGamalDecrypt(g, key.Private, ElGamalMul(g, c1, c2)); m.Cmp(want) != 0 {
+		t.Errorf("%s: homomorphic multiplication failed", t.Name())
+	}
+
+	if _, err := ElGamalEncrypt(g, rand.Reader, key.Public, p); err != ErrMessageOutOfRange {
+		t.Errorf("%s: message out of range was accepted", t.Name())
+	}
+
+	// A small-order C1 is rejected when the subgroup check is enabled.
+	pm1 := new(big.Int).Sub(p, big.NewInt(1))
+	bad := &ElGamalCiphertext{C1: pm1, C2: big.NewInt(1)}
+	if _, err := ElGamalDecrypt(WithValidation(g, SubgroupCheck), key.Private, bad); err == nil {
+		t.Errorf("%s: invalid ciphertext was decrypted", t.Name())
+	}
+}
+
+func TestSRPGroups(t *testing.T) {
+	for _, v := range []ID{Srp1024, Srp1536, Srp2048, Srp3072, Srp4096, Srp6144, Srp8192} {
+		g, _ := GroupForGroupID(v)
+		params := g.DHParams()
+
+		if !params.Q.ProbablyPrime(1) {
+			t.Errorf("%s: Q is not prime for %s", t.Name(), g.DHName())
+		}
+		if params.Cofactor().Cmp(big.NewInt(2)) != 0 {
+			t.Errorf("%s: cofactor is not 2 for %s", t.Name(), g.DHName())
+		}
+		if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("%s: g^Q != 1 mod p for %s", t.Name(), g.DHName())
+		}
+		// The SRP generator is a non-residue of order p-1.
+		if new(big.Int).Exp(params.SRPGenerator, params.Q, params.P).Cmp(big.NewInt(1)) == 0 {
+			t.Errorf("%s: SRP generator is a quadratic residue for %s", t.Name(), g.DHName())
+		}
+		if new(big.Int).Exp(params.SRPGenerator, big.NewInt(2), params.P).Cmp(params.G) != 0 {
+			t.Errorf("%s: G != g^2 for %s", t.Name(), g.DHName())
+		}
+	}
+}
+
+func TestSRP(t *testing.T) {
+	// Test vectors from RFC 5054, Appendix B.
+	srp := &SRP{Group: SRP1024(), Hash: crypto.SHA1}
+	salt := bigFromBase16("BEB25379D1A8581EB5A727673A2441EE").Bytes()
+	a := bigFromBase16("60975527035CF2AD1989806F0407210BC81EDC04E2762A56AFD529DDDA2D4393")
+	b := bigFromBase16("E487CB59D31AC550471E81F00F6928E01DDA08E974A004F49E61F5D105284D20")
+
+	v := srp.newVerifier("alice", salt, []byte("password123"))
+	client := srp.newClient("alice", []byte("password123"), a)
+	server := srp.newServer(v, b)
+
+	vectors := []struct {
+		name      string
+		got, want *big.Int
+	}{
+		{"k", srp.multiplier(), bigFromBase16("7556AA045AEF2CDD07ABAF0F665C3E818913186F")},
+		{"x", srp.privateKey("alice", salt, []byte("password123")), bigFromBase16("94B7555AABE9127CC58CCF4993DB6CF84D16C124")},
+		{"v", v.V, bigFromBase16("7E273DE8696FFC4F4E337D05B4B375BEB0DDE1569E8FA00A" +
+			"9886D8129BADA1F1822223CA1A605B530E379BA4729FDC59" +
+			"F105B4787E5186F5C671085A1447B52A48CF1970B4FB6F84" +
+			"00BBF4CEBFBB168152E08AB5EA53D15C1AFF87B2B9DA6E04" +
+			"E058AD51CC72BFC9033B564E26480D78E955A5E29E7AB245" +
+			"DB2BE315E2099AFB")},
+		{"A", client.A, bigFromBase16("61D5E490F6F1B79547B0704C436F523DD0E560F0C64115BB" +
+			"72557EC44352E8903211C04692272D8B2D1A5358A2CF1B6E" +
+			"0BFCF99F921530EC8E39356179EAE45E42BA92AEACED8251" +
+			"71E1E8B9AF6D9C03E1327F44BE087EF06530E69F66615261" +
+			"EEF54073CA11CF5858F0EDFDFE15EFEAB349EF5D76988A36" +
+			"72FAC47B0769447B")},
+		{"B", server.B, bigFromBase16("BD0C61512C692C0CB6D041FA01BB152D4916A1E77AF46AE1" +
+			"05393011BAF38964DC46A0670DD125B95A981652236F99D9" +
+			"B681CBF87837EC996C6DA04453728610D0C6DDB58B318885" +
+			"D7D82C7F8DEB75CE7BD4FBAA37089E6F9C6059F388838E7A" +
+			"00030B331EB76840910440B1B27AAEAEEB4012B7D7665238" +
+			"A8E3FB004B117B58")},
+		{"u", srp.scrambler(client.A, server.B), bigFromBase16("CE38B9593487DA98554ED47D70A7AE5F462EF019")},
+		{"S", server.premasterSecret(client.A), bigFromBase16("B0DC82BABCF30674AE450C0287745E7990A3381F63B387AA" +
+			"F271A10D233861E359B48220F7C4693C9AE12B0A6F67809F" +
+			"0876E2D013800D6C41BB59B6D5979B5C00A172B4A2A5903A" +
+			"0BDCAF8A709585EB2AFAFA8F3499B200210DCC1F10EB3394" +
+			"3CD67FC88A2F39A4BE5BEC4EC0A3212DC346D7E474B29EDE" +
+			"8A469FFECA686E5A")},
+	}
+	for _, tt := range vectors {
+		if tt.got.Cmp(tt.want) != 0 {
+			t.Errorf("%s: wrong %s", t.Name(), tt.name)
+		}
+	}
+
+	m1, err := client.Proof(server.Salt(), server.B)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	m2, err := server.Verify(client.A, m1)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if !client.VerifyServer(m2) || !bytes.Equal(client.Key(), server.Key()) {
+		t.Fatalf("%s: key exchange failed", t.Name())
+	}
+}
+
+func TestSRPAuthentication(t *testing.T) {
+	srp := &SRP{Group: SRP2048()}
+	v, _ := srp.NewVerifier(rand.Reader, "alice", []byte("password123"))
+
+	for _, tt := range []struct {
+		password string
+		err      error
+	}{
+		{"password123", nil},
+		{"password124", ErrSRPAuthFailed},
+	} {
+		client, _ := srp.NewClient(rand.Reader, "alice", []byte(tt.password))
+		server, _ := srp.NewServer(rand.Reader, v)
+		m1, err := client.Proof(server.Salt(), server.B)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if _, err := server.Verify(client.A, m1); err != tt.err {
+			t.Errorf("%s: password %q: got %v, want %v", t.Name(), tt.password, err, tt.err)
+		}
+	}
+
+	// The server rejects A = 0 and A = N, the client rejects B = 0.
+	p := srp.Group.DHParams().P
+	server, _ := srp.NewServer(rand.Reader, v)
+	for _, A := range []*big.Int{new(big.Int), p, new(big.Int).Lsh(p, 1)} {
+		if _, err := server.Verify(A, nil); err != ErrSRPInvalidPublicKey {
+			t.Errorf("%s: A = %d*N was accepted", t.Name(), new(big.Int).Div(A, p))
+		}
+	}
+	client, _ := srp.NewClient(rand.Reader, "alice", []byte("password123"))
+	if _, err := client.Proof(v.Salt, p); err != ErrSRPInvalidPublicKey {
+		t.Errorf("%s: B = N was accepted", t.Name())
+	}
+}
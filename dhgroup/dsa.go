@@ -0,0 +1,114 @@
+package dhgroup
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// NonceSource returns a DSA per-signature nonce k in [1, q).
+// Real implementations must use a fresh random or deterministic (RFC 6979) nonce;
+// the labs plug in broken sources to demonstrate nonce attacks.
+type NonceSource func(q *big.Int) (*big.Int, error)
+
+// RandomNonce returns a NonceSource choosing nonces uniformly from [1, q) using rng.
+func RandomNonce(rng io.Reader) NonceSource {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	return func(q *big.Int) (*big.Int, error) {
+		k, err := rand.Int(rng, new(big.Int).Sub(q, big.NewInt(1)))
+		if err != nil {
+			return nil, err
+		}
+		return k.Add(k, big.NewInt(1)), nil
+	}
+}
+
+// ErrInvalidNonce is returned by Sign when the nonce source gives a nonce out of [1, q)
+// or nonces that do not produce a valid signature.
+var ErrInvalidNonce = errors.New("dhgroup: invalid DSA nonce")
+
+// dsaAttempts limits the number of nonces Sign requests before giving up.
+const dsaAttempts = 10
+
+// DSAHashToInt converts a hash value to an integer as FIPS 186-4 requires:
+// the leftmost min(N, outlen) bits of the hash are used, where N is the bit length of q.
+func DSAHashToInt(hash []byte, q *big.Int) *big.Int {
+	orderBits := q.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+	h := new(big.Int).SetBytes(hash)
+	if excess := len(hash)*8 - orderBits; excess > 0 {
+		h.Rsh(h, uint(excess))
+	}
+	return h
+}
+
+// Sign signs a hash with the private key x using DSA over the subgroup of order Q
+// generated by G. The DH key pairs of the group are valid DSA key pairs.
+func (g GroupParams) Sign(x *big.Int, hash []byte, nonce NonceSource) (r, s *big.Int, err error) {
+	if nonce == nil {
+		nonce = RandomNonce(nil)
+	}
+	h := DSAHashToInt(hash, g.Q)
+
+	for i := 0; i < dsaAttempts; i++ {
+		k, err := nonce(g.Q)
+		if err != nil {
+			return nil, nil, err
+		}
+		if k.Sign() <= 0 || k.Cmp(g.Q) >= 0 {
+			return nil, nil, ErrInvalidNonce
+		}
+
+		kInv := new(big.Int).ModInverse(k, g.Q)
+		if kInv == nil {
+			continue
+		}
+
+		r = g.ScalarBaseExp(k)
+		r.Mod(r, g.Q)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		// s = k^-1 * (h + x*r) mod q
+		s = new(big.Int).Mul(x, r)
+		s.Add(s, h)
+		s.Mul(s, kInv)
+		s.Mod(s, g.Q)
+		if s.Sign() == 0 {
+			continue
+		}
+		return r, s, nil
+	}
+	return nil, nil, ErrInvalidNonce
+}
+
+// Verify reports whether (r, s) is a valid DSA signature of hash for the public key y.
+func (g GroupParams) Verify(y *big.Int, hash []byte, r, s *big.Int) bool {
+	if r.Sign() <= 0 || r.Cmp(g.Q) >= 0 || s.Sign() <= 0 || s.Cmp(g.Q) >= 0 {
+		return false
+	}
+	w := new(big.Int).ModInverse(s, g.Q)
+	if w == nil {
+		return false
+	}
+
+	u1 := DSAHashToInt(hash, g.Q)
+	u1.Mul(u1, w)
+	u1.Mod(u1, g.Q)
+	u2 := w.Mul(r, w)
+	u2.Mod(u2, g.Q)
+
+	// v = (g^u1 * y^u2 mod p) mod q
+	v := g.ScalarBaseExp(u1)
+	v.Mul(v, new(big.Int).Exp(y, u2, g.P))
+	v.Mod(v, g.P)
+	v.Mod(v, g.Q)
+	return v.Cmp(r) == 0
+}
@@ -0,0 +1,68 @@
+package dhgroup
+
+import (
+	"errors"
+	"io"
+	"math/big"
+)
+
+// ElGamalCiphertext is an ElGamal ciphertext (C1, C2) = (g^k, m*y^k).
+type ElGamalCiphertext struct {
+	C1, C2 *big.Int
+}
+
+// ErrMessageOutOfRange is returned when an ElGamal message is not in [1, p).
+var ErrMessageOutOfRange = errors.New("dhgroup: message is out of range")
+
+// ElGamalEncrypt encrypts the group element m for the public key y.
+// The ephemeral key is generated by the group using rng.
+func ElGamalEncrypt(group DHScheme, rng io.Reader, y, m *big.Int) (*ElGamalCiphertext, error) {
+	p := group.DHParams().P
+	if m.Sign() <= 0 || m.Cmp(p) >= 0 {
+		return nil, ErrMessageOutOfRange
+	}
+
+	ephemeral, err := group.GenerateKey(rng)
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).Exp(y, ephemeral.Private, p)
+	c2 := s.Mul(s, m)
+	c2.Mod(c2, p)
+	return &ElGamalCiphertext{C1: ephemeral.Public, C2: c2}, nil
+}
+
+// ElGamalDecrypt decrypts the ciphertext with the private key x: m = C2 / C1^x.
+// C1^x is computed with DH, so the validation policy of the group applies to C1.
+func ElGamalDecrypt(group DHScheme, x *big.Int, c *ElGamalCiphertext) (*big.Int, error) {
+	p := group.DHParams().P
+	s, err := group.DH(x, c.C1)
+	if err != nil {
+		return nil, err
+	}
+	sInv := new(big.Int).ModInverse(s, p)
+	if sInv == nil {
+		return nil, errors.New("dhgroup: invalid ElGamal ciphertext")
+	}
+	m := sInv.Mul(sInv, c.C2)
+	return m.Mod(m, p), nil
+}
+
+// ElGamalRerandomize returns a fresh encryption of the same message:
+// (C1*g^k, C2*y^k) for a random k. It does not require the private key.
+func ElGamalRerandomize(group DHScheme, rng io.Reader, y *big.Int, c *ElGamalCiphertext) (*ElGamalCiphertext, error) {
+	one, err := ElGamalEncrypt(group, rng, y, big.NewInt(1))
+	if err != nil {
+		return nil, err
+	}
+	return ElGamalMul(group, c, one), nil
+}
+
+// ElGamalMul returns an encryption of m1*m2 given encryptions of m1 and m2.
+// ElGamal is multiplicatively homomorphic and thus malleable.
+func ElGamalMul(group DHScheme, a, b *ElGamalCiphertext) *ElGamalCiphertext {
+	p := group.DHParams().P
+	c1 := new(big.Int).Mul(a.C1, b.C1)
+	c2 := new(big.Int).Mul(a.C2, b.C2)
+	return &ElGamalCiphertext{C1: c1.Mod(c1, p), C2: c2.Mod(c2, p)}
+}
@@ -0,0 +1,385 @@
+package dhgroup
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// PEM block types used by OpenSSL for DH parameters and keys.
+const (
+	PEMTypePKCS3Parameters = "DH PARAMETERS"
+	PEMTypeX942Parameters  = "X9.42 DH PARAMETERS"
+	PEMTypePublicKey       = "PUBLIC KEY"
+	PEMTypePrivateKey      = "PRIVATE KEY"
+)
+
+var (
+	// oidDHKeyAgreement is the PKCS#3 dhKeyAgreement OID.
+	oidDHKeyAgreement = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 3, 1}
+	// oidDHPublicNumber is the X9.42 dhpublicnumber OID.
+	oidDHPublicNumber = asn1.ObjectIdentifier{1, 2, 840, 10046, 2, 1}
+)
+
+// pkcs3Parameters is the PKCS#3 DHParameter structure:
+//
+//	DHParameter ::= SEQUENCE {
+//	  prime INTEGER, -- p
+//	  base INTEGER, -- g
+//	  privateValueLength INTEGER OPTIONAL }
+type pkcs3Parameters struct {
+	P                  *big.Int
+	G                  *big.Int
+	PrivateValueLength int `asn1:"optional"`
+}
+
+// x942Parameters is the X9.42 DomainParameters structure:
+//
+//	DomainParameters ::= SEQUENCE {
+//	  p INTEGER, -- odd prime, p=jq +1
+//	  g INTEGER, -- generator, g
+//	  q INTEGER, -- factor of p-1
+//	  j INTEGER OPTIONAL, -- subgroup factor
+//	  validationParms ValidationParms OPTIONAL }
+//
+//	ValidationParms ::= SEQUENCE {
+//	  seed BIT STRING,
+//	  pgenCounter INTEGER }
+type x942Parameters struct {
+	P               *big.Int
+	G               *big.Int
+	Q               *big.Int
+	J               *big.Int      `asn1:"optional"`
+	ValidationParms asn1.RawValue `asn1:"optional"`
+}
+
+type x942ValidationParms struct {
+	Seed        asn1.BitString
+	PGenCounter int
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type privateKeyInfo struct {
+	Version    int
+	Algorithm  algorithmIdentifier
+	PrivateKey []byte
+}
+
+// MarshalPKCS3Parameters converts the group parameters to the PKCS#3 DHParameter DER form.
+// PKCS#3 does not carry the subgroup order, so Q is lost. ExponentBits is encoded
+// as privateValueLength.
+func MarshalPKCS3Parameters(g *GroupParams) ([]byte, error) {
+	return asn1.Marshal(pkcs3Parameters{P: g.P, G: g.G, PrivateValueLength: g.ExponentBits})
+}
+
+// ParsePKCS3Parameters parses PKCS#3 DHParameter DER form.
+//
+// As PKCS#3 does not define the subgroup order, Q is set to (p-1)/2 if it is
+// prime (p is a safe prime) and g is a quadratic residue (g^((p-1)/2) = 1),
+// and to p-1 otherwise. The optional privateValueLength is mapped to ExponentBits.
+// The parameters are validated as in RegisterGroup.
+func ParsePKCS3Parameters(der []byte) (*GroupParams, error) {
+	var params pkcs3Parameters
+	rest, err := asn1.Unmarshal(der, &params)
+	if err != nil {
+		return nil, fmt.Errorf("dhgroup: failed to parse PKCS#3 parameters: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("dhgroup: trailing data after PKCS#3 parameters")
+	}
+	if err := checkParameters(params.P, params.G); err != nil {
+		return nil, err
+	}
+	if params.PrivateValueLength < 0 || params.PrivateValueLength >= params.P.BitLen() {
+		return nil, errors.New("dhgroup: invalid PKCS#3 private value length")
+	}
+
+	g := &GroupParams{
+		P:            params.P,
+		G:            params.G,
+		Name:         fmt.Sprintf("PKCS3-%d", params.P.BitLen()),
+		BitSize:      params.P.BitLen(),
+		ExponentBits: params.PrivateValueLength,
+	}
+	q := new(big.Int).Rsh(params.P, 1)
+	if q.ProbablyPrime(20) && new(big.Int).Exp(params.G, q, params.P).Cmp(big.NewInt(1)) == 0 {
+		g.Q = q
+		g.factors = []Factor{{big.NewInt(2), 1}}
+	} else {
+		g.Q = new(big.Int).Sub(params.P, big.NewInt(1))
+	}
+	if err := validateGroup(g); err != nil {
+		return nil, err
+	}
+	g.base = newFixedBase(g.G, g.P, g.Q.BitLen())
+	return g, nil
+}
+
+// MarshalX942Parameters converts the group parameters to the X9.42 DomainParameters
+// DER form. The subgroup factor j is set to the cofactor (p-1)/Q.
+func MarshalX942Parameters(g *GroupParams) ([]byte, error) {
+	return asn1.Marshal(x942Parameters{P: g.P, G: g.G, Q: g.Q, J: g.Cofactor()})
+}
+
+// ParseX942Parameters parses X9.42 DomainParameters DER form.
+// If the optional j is present, it must be equal to (p-1)/q.
+// The optional validation parameters are checked for syntax only.
+// The parameters are validated as in RegisterGroup.
+func ParseX942Parameters(der []byte) (*GroupParams, error) {
+	var params x942Parameters
+	rest, err := asn1.Unmarshal(der, &params)
+	if err != nil {
+		return nil, fmt.Errorf("dhgroup: failed to parse X9.42 parameters: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("dhgroup: trailing data after X9.42 parameters")
+	}
+	if err := checkParameters(params.P, params.G); err != nil {
+		return nil, err
+	}
+	if params.Q == nil || params.Q.Sign() <= 0 {
+		return nil, errors.New("dhgroup: invalid X9.42 subgroup order")
+	}
+
+	j, m := new(big.Int).DivMod(new(big.Int).Sub(params.P, big.NewInt(1)), params.Q, new(big.Int))
+	if m.Sign() != 0 {
+		return nil, errors.New("dhgroup: q does not divide p-1")
+	}
+	if params.J != nil && params.J.Cmp(j) != 0 {
+		return nil, errors.New("dhgroup: j is not equal to (p-1)/q")
+	}
+	if len(params.ValidationParms.FullBytes) != 0 {
+		var v x942ValidationParms
+		if _, err := asn1.Unmarshal(params.ValidationParms.FullBytes, &v); err != nil {
+			return nil, fmt.Errorf("dhgroup: failed to parse X9.42 validation parameters: %v", err)
+		}
+	}
+
+	g := &GroupParams{
+		P:       params.P,
+		G:       params.G,
+		Q:       params.Q,
+		Name:    fmt.Sprintf("X942-%d-%d", params.P.BitLen(), params.Q.BitLen()),
+		BitSize: params.P.BitLen(),
+	}
+	if err := validateGroup(g); err != nil {
+		return nil, err
+	}
+	g.base = newFixedBase(g.G, g.P, g.Q.BitLen())
+	return g, nil
+}
+
+// MarshalPKIXPublicKey converts a DH public key to the SubjectPublicKeyInfo DER form.
+// The X9.42 dhpublicnumber algorithm is used, so the subgroup order is preserved.
+func MarshalPKIXPublicKey(g *GroupParams, public *big.Int) ([]byte, error) {
+	alg, err := x942AlgorithmIdentifier(g)
+	if err != nil {
+		return nil, err
+	}
+	y, err := asn1.Marshal(public)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: alg,
+		PublicKey: asn1.BitString{Bytes: y, BitLength: 8 * len(y)},
+	})
+}
+
+// ParsePKIXPublicKey parses a DH public key in the SubjectPublicKeyInfo DER form.
+// Both PKCS#3 dhKeyAgreement and X9.42 dhpublicnumber algorithms are supported.
+func ParsePKIXPublicKey(der []byte) (*GroupParams, *big.Int, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dhgroup: failed to parse public key: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, nil, errors.New("dhgroup: trailing data after public key")
+	}
+
+	g, err := parseAlgorithmIdentifier(spki.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	y := new(big.Int)
+	if rest, err := asn1.Unmarshal(spki.PublicKey.RightAlign(), &y); err != nil || len(rest) != 0 {
+		return nil, nil, errors.New("dhgroup: failed to parse public value")
+	}
+	if y.Sign() <= 0 || y.Cmp(g.P) >= 0 {
+		return nil, nil, ErrPublicKeyOutOfRange
+	}
+	return g, y, nil
+}
+
+// MarshalPKCS8PrivateKey converts a DH key pair to the PKCS#8 PrivateKeyInfo DER form.
+func MarshalPKCS8PrivateKey(g *GroupParams, key DHKey) ([]byte, error) {
+	alg, err := x942AlgorithmIdentifier(g)
+	if err != nil {
+		return nil, err
+	}
+	x, err := asn1.Marshal(key.Private)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(privateKeyInfo{Algorithm: alg, PrivateKey: x})
+}
+
+// ParsePKCS8PrivateKey parses a DH private key in the PKCS#8 PrivateKeyInfo DER form
+// and recomputes the public key.
+func ParsePKCS8PrivateKey(der []byte) (*GroupParams, DHKey, error) {
+	var pki privateKeyInfo
+	rest, err := asn1.Unmarshal(der, &pki)
+	if err != nil {
+		return nil, DHKey{}, fmt.Errorf("dhgroup: failed to parse private key: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, DHKey{}, errors.New("dhgroup: trailing data after private key")
+	}
+	if pki.Version != 0 {
+		return nil, DHKey{}, fmt.Errorf("dhgroup: unsupported private key version %d", pki.Version)
+	}
+
+	g, err := parseAlgorithmIdentifier(pki.Algorithm)
+	if err != nil {
+		return nil, DHKey{}, err
+	}
+
+	x := new(big.Int)
+	if rest, err := asn1.Unmarshal(pki.PrivateKey, &x); err != nil || len(rest) != 0 {
+		return nil, DHKey{}, errors.New("dhgroup: failed to parse private value")
+	}
+	if x.Sign() <= 0 || x.Cmp(g.Q) >= 0 {
+		return nil, DHKey{}, errors.New("dhgroup: private value is out of range")
+	}
+	return g, DHKey{Private: x, Public: new(big.Int).Exp(g.G, x, g.P)}, nil
+}
+
+// ParseParametersPEM parses the first PEM block with DH parameters in data.
+// Both PKCS#3 and X9.42 parameters are supported. Unrelated PEM blocks are skipped.
+func ParseParametersPEM(data []byte) (*GroupParams, error) {
+	block := findPEMBlock(data, PEMTypePKCS3Parameters, PEMTypeX942Parameters)
+	if block == nil {
+		return nil, errors.New("dhgroup: no DH parameters found")
+	}
+	if block.Type == PEMTypePKCS3Parameters {
+		return ParsePKCS3Parameters(block.Bytes)
+	}
+	return ParseX942Parameters(block.Bytes)
+}
+
+// findPEMBlock returns the first PEM block in data of one of the given types.
+func findPEMBlock(data []byte, types ...string) *pem.Block {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		for _, t := range types {
+			if block.Type == t {
+				return block
+			}
+		}
+	}
+}
+
+// EncodePKCS3ParametersPEM returns the group parameters as a PKCS#3 PEM block.
+func EncodePKCS3ParametersPEM(g *GroupParams) ([]byte, error) {
+	der, err := MarshalPKCS3Parameters(g)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePKCS3Parameters, Bytes: der}), nil
+}
+
+// EncodeX942ParametersPEM returns the group parameters as an X9.42 PEM block.
+func EncodeX942ParametersPEM(g *GroupParams) ([]byte, error) {
+	der, err := MarshalX942Parameters(g)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypeX942Parameters, Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM parses the first PEM encoded public key in data.
+// Unrelated PEM blocks are skipped.
+func ParsePublicKeyPEM(data []byte) (*GroupParams, *big.Int, error) {
+	block := findPEMBlock(data, PEMTypePublicKey)
+	if block == nil {
+		return nil, nil, errors.New("dhgroup: no public key found")
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+// EncodePublicKeyPEM returns the public key as a PEM block.
+func EncodePublicKeyPEM(g *GroupParams, public *big.Int) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(g, public)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePublicKey, Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM parses the first PEM encoded PKCS#8 private key in data.
+// Unrelated PEM blocks are skipped.
+func ParsePrivateKeyPEM(data []byte) (*GroupParams, DHKey, error) {
+	block := findPEMBlock(data, PEMTypePrivateKey)
+	if block == nil {
+		return nil, DHKey{}, errors.New("dhgroup: no private key found")
+	}
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// EncodePrivateKeyPEM returns the key pair as a PKCS#8 PEM block.
+func EncodePrivateKeyPEM(g *GroupParams, key DHKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(g, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePrivateKey, Bytes: der}), nil
+}
+
+func x942AlgorithmIdentifier(g *GroupParams) (algorithmIdentifier, error) {
+	params, err := MarshalX942Parameters(g)
+	if err != nil {
+		return algorithmIdentifier{}, err
+	}
+	return algorithmIdentifier{
+		Algorithm:  oidDHPublicNumber,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}, nil
+}
+
+func parseAlgorithmIdentifier(alg algorithmIdentifier) (*GroupParams, error) {
+	switch {
+	case alg.Algorithm.Equal(oidDHPublicNumber):
+		return ParseX942Parameters(alg.Parameters.FullBytes)
+	case alg.Algorithm.Equal(oidDHKeyAgreement):
+		return ParsePKCS3Parameters(alg.Parameters.FullBytes)
+	}
+	return nil, fmt.Errorf("dhgroup: unsupported algorithm %v", alg.Algorithm)
+}
+
+// checkParameters performs basic sanity checks of p and g.
+func checkParameters(p, g *big.Int) error {
+	if p == nil || p.Sign() <= 0 || p.Bit(0) == 0 {
+		return errors.New("dhgroup: invalid prime")
+	}
+	if g == nil || g.Cmp(big.NewInt(1)) <= 0 || g.Cmp(p) >= 0 {
+		return errors.New("dhgroup: invalid generator")
+	}
+	return nil
+}
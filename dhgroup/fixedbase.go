@@ -0,0 +1,92 @@
+package dhgroup
+
+import (
+	"math/big"
+	"sync"
+)
+
+// fixedBaseWindow is the window size in bits of the fixed-base table.
+const fixedBaseWindow = 5
+
+// fixedBaseMaxBits is the largest size of p with a fixed-base table. For larger groups
+// the table takes longer to build than many exponentiations and uses tens of megabytes.
+const fixedBaseMaxBits = 2048
+
+// fixedBase is a precomputed table for exponentiation with the fixed base G:
+// table[i][j-1] = G^(j*2^(w*i)) mod p for 1 <= j < 2^w.
+// Then G^k is a product of one table entry per w-bit digit of k and
+// needs no squarings at all.
+//
+// The table is shared by all copies of GroupParams. It is created for the G and p of the
+// group and is built lazily from them, so a copy with another G or P can neither use nor build it.
+type fixedBase struct {
+	once  sync.Once
+	g, p  *big.Int
+	bits  int
+	table [][]*big.Int
+}
+
+// newFixedBase returns the table for the base g modulo p and the exponents of
+// the given size, or nil if p is larger than fixedBaseMaxBits.
+func newFixedBase(g, p *big.Int, bits int) *fixedBase {
+	if p.BitLen() > fixedBaseMaxBits {
+		return nil
+	}
+	// The table covers the exponents of bits rounded up to the window size.
+	n := (bits + fixedBaseWindow - 1) / fixedBaseWindow
+	return &fixedBase{g: new(big.Int).Set(g), p: new(big.Int).Set(p), bits: n * fixedBaseWindow}
+}
+
+func (f *fixedBase) init() {
+	f.once.Do(func() {
+		n := f.bits / fixedBaseWindow
+		f.table = make([][]*big.Int, n)
+		base := new(big.Int).Set(f.g)
+		for i := 0; i < n; i++ {
+			row := make([]*big.Int, 1<<fixedBaseWindow-1)
+			row[0] = new(big.Int).Set(base)
+			for j := 1; j < len(row); j++ {
+				row[j] = new(big.Int).Mul(row[j-1], base)
+				row[j].Mod(row[j], f.p)
+			}
+			f.table[i] = row
+			// The next base is G^(2^(w*(i+1))).
+			base.Mul(row[len(row)-1], base)
+			base.Mod(base, f.p)
+		}
+	})
+}
+
+// matches reports whether the table is for the base g modulo p.
+func (f *fixedBase) matches(g, p *big.Int) bool {
+	return f.g.Cmp(g) == 0 && f.p.Cmp(p) == 0
+}
+
+// ScalarBaseExp returns G^k mod p. For groups with a precomputed table
+// (the groups of the package, parsed, generated and registered groups with p
+// of at most 2048 bits) it uses the fixed-base windowed method; the table is built
+// on the first call. Copies of a group with a changed G or P use the generic exponentiation.
+func (g GroupParams) ScalarBaseExp(k *big.Int) *big.Int {
+	if g.base == nil || k.Sign() < 0 || k.BitLen() > g.base.bits || !g.base.matches(g.G, g.P) {
+		return new(big.Int).Exp(g.G, k, g.P)
+	}
+	g.base.init()
+
+	r := big.NewInt(1)
+	for i, row := range g.base.table {
+		if d := window(k, i*fixedBaseWindow); d != 0 {
+			r.Mul(r, row[d-1])
+			r.Mod(r, g.P)
+		}
+	}
+	return r
+}
+
+// window returns fixedBaseWindow bits of k starting from the bit offset.
+func window(k *big.Int, offset int) uint {
+	var d uint
+	for b := 0; b < fixedBaseWindow; b++ {
+		d |= k.Bit(offset+b) << uint(b)
+	}
+	return d
+}
@@ -0,0 +1,149 @@
+package dhgroup
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// builtinGroups lists the IDs of the groups defined by the package.
+var builtinGroups = []ID{
+	ModP512v57, ModP512v58,
+	ModP768, ModP1536, ModP2048,
+	ModP1024s160, ModP2048s224, ModP2048s256,
+	Ffdhe2048, Ffdhe3072, Ffdhe4096, Ffdhe6144, Ffdhe8192,
+	Srp1024, Srp1536, Srp2048, Srp3072, Srp4096, Srp6144, Srp8192,
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[ID]*GroupParams)
+)
+
+// RegisterGroup makes a custom group available by id through GroupForGroupID and
+// by name through GroupForName. The parameters are deep-copied and validated,
+// so changing params afterwards does not change the registered group:
+// p must be prime, Q must divide p-1 and g must be in the subgroup of order Q.
+// It is an error to register a group with an ID or a name that is already in use.
+func RegisterGroup(id ID, params *GroupParams) error {
+	if params == nil || params.P == nil || params.G == nil || params.Q == nil {
+		return errors.New("dhgroup: incomplete group parameters")
+	}
+	if params.Name == "" {
+		return errors.New("dhgroup: group name is empty")
+	}
+	if err := validateGroup(params); err != nil {
+		return err
+	}
+
+	if _, err := builtinGroupForGroupID(id); err == nil {
+		return fmt.Errorf("dhgroup: group id %d is reserved", id)
+	}
+	if g, _ := builtinGroupForName(params.Name); g != nil {
+		return fmt.Errorf("dhgroup: group name %q is reserved", params.Name)
+	}
+
+	g := params.clone()
+	if g.BitSize == 0 {
+		g.BitSize = g.P.BitLen()
+	}
+	g.base = newFixedBase(g.G, g.P, g.Q.BitLen())
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[id]; ok {
+		return fmt.Errorf("dhgroup: group id %d is already registered", id)
+	}
+	for _, r := range registry {
+		if r.Name == g.Name {
+			return fmt.Errorf("dhgroup: group name %q is already registered", g.Name)
+		}
+	}
+	registry[id] = g
+	return nil
+}
+
+// clone returns a deep copy of the group parameters without the fixed-base table.
+func (g *GroupParams) clone() *GroupParams {
+	c := *g
+	c.P = copyInt(g.P)
+	c.G = copyInt(g.G)
+	c.Q = copyInt(g.Q)
+	c.SRPGenerator = copyInt(g.SRPGenerator)
+	c.factors = make([]Factor, len(g.factors))
+	for i, f := range g.factors {
+		c.factors[i] = Factor{copyInt(f.Prime), f.Exp}
+	}
+	c.base = nil
+	return &c
+}
+
+// copyInt returns a copy of x or nil if x is nil.
+func copyInt(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+// UnregisterGroup removes a custom group registered with RegisterGroup.
+func UnregisterGroup(id ID) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, id)
+}
+
+// GroupForName returns the group with the given name, e.g. "MODP-2048" or "ffdhe3072".
+func GroupForName(name string) (DHScheme, error) {
+	if g, _ := builtinGroupForName(name); g != nil {
+		return g, nil
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, g := range registry {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("dhgroup: Unknown or unsupported group name: %s", name)
+}
+
+func registeredGroup(id ID) (DHScheme, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[id]
+	if !ok {
+		return nil, fmt.Errorf("dhgroup: Unknown or unsupported group id: %d", id)
+	}
+	return g, nil
+}
+
+func builtinGroupForName(name string) (DHScheme, error) {
+	for _, id := range builtinGroups {
+		g, _ := builtinGroupForGroupID(id)
+		if g.DHName() == name {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("dhgroup: Unknown or unsupported group name: %s", name)
+}
+
+// validateGroup checks that p is prime, Q divides p-1 and g^Q = 1 mod p.
+func validateGroup(g *GroupParams) error {
+	if !g.P.ProbablyPrime(20) {
+		return errors.New("dhgroup: p is not prime")
+	}
+	pm1 := new(big.Int).Sub(g.P, big.NewInt(1))
+	if g.Q.Cmp(big.NewInt(1)) <= 0 || new(big.Int).Mod(pm1, g.Q).Sign() != 0 {
+		return errors.New("dhgroup: Q does not divide p-1")
+	}
+	if g.G.Cmp(big.NewInt(1)) <= 0 || g.G.Cmp(pm1) >= 0 {
+		return errors.New("dhgroup: generator is out of range")
+	}
+	if new(big.Int).Exp(g.G, g.Q, g.P).Cmp(big.NewInt(1)) != 0 {
+		return errors.New("dhgroup: generator is not in the subgroup of order Q")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package dhgroup
+
+import (
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"sync"
+	"testing"
+)
+
+func TestRegisterGroup(t *testing.T) {
+	g, _, err := GenerateWeakGroup(mrand.New(mrand.NewSource(2)), WeakGroupConfig{QBits: 64, SmoothnessBound: 1 << 12, SmallFactors: 4})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	g.Name = "test-weak"
+
+	const id ID = 1000
+	if err := RegisterGroup(id, g); err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	defer UnregisterGroup(id)
+
+	byID, err := GroupForGroupID(id)
+	if err != nil || byID.DHParams().P.Cmp(g.P) != 0 {
+		t.Fatalf("%s: group was not found by id", t.Name())
+	}
+	byName, err := GroupForName("test-weak")
+	if err != nil || byName.DHParams().P.Cmp(g.P) != 0 {
+		t.Fatalf("%s: group was not found by name", t.Name())
+	}
+
+	// The registered group does not alias the caller's parameters.
+	p, gen := new(big.Int).Set(g.P), new(big.Int).Set(g.G)
+	g.P.Add(g.P, big.NewInt(2))
+	g.G.SetInt64(1)
+	if r := byID.DHParams(); r.P.Cmp(p) != 0 || r.G.Cmp(gen) != 0 {
+		t.Errorf("%s: changing the parameters changed the registered group", t.Name())
+	}
+	g.P.Set(p)
+	g.G.Set(gen)
+
+	if err := RegisterGroup(id, g); err == nil {
+		t.Errorf("%s: duplicate id was accepted", t.Name())
+	}
+	if err := RegisterGroup(id+1, g); err == nil {
+		t.Errorf("%s: duplicate name was accepted", t.Name())
+	}
+	if err := RegisterGroup(ModP2048, g); err == nil {
+		t.Errorf("%s: built-in id was accepted", t.Name())
+	}
+}
+
+func TestRegisterInvalidGroup(t *testing.T) {
+	good := MODP512V57().DHParams()
+
+	var invalidGroups = []*GroupParams{
+		nil,
+		{P: good.P, G: good.G, Name: "no-q"},
+		{P: new(big.Int).Add(good.P, big.NewInt(2)), G: good.G, Q: good.Q, Name: "composite-p"},
+		{P: good.P, G: good.G, Q: new(big.Int).Add(good.Q, big.NewInt(2)), Name: "wrong-q"},
+		{P: good.P, G: big.NewInt(2), Q: good.Q, Name: "wrong-g"},
+		{P: good.P, G: good.G, Q: good.Q, Name: ""},
+		{P: good.P, G: good.G, Q: good.Q, Name: "MODP-2048"},
+	}
+
+	for i, g := range invalidGroups {
+		if err := RegisterGroup(ID(2000+i), g); err == nil {
+			UnregisterGroup(ID(2000 + i))
+			t.Errorf("%s - #%d: invalid group was accepted", t.Name(), i)
+		}
+	}
+}
+
+func TestGroupForName(t *testing.T) {
+	for _, id := range builtinGroups {
+		g, _ := GroupForGroupID(id)
+		g1, err := GroupForName(g.DHName())
+		if err != nil || g1 != g {
+			t.Errorf("%s: %s was not found by name", t.Name(), g.DHName())
+		}
+	}
+	if _, err := GroupForName("unknown"); err == nil {
+		t.Errorf("%s: unknown group was found", t.Name())
+	}
+}
+
+func TestRegisterGroupConcurrently(t *testing.T) {
+	g := MODP512V57().DHParams()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := ID(3000 + i)
+			params := *g
+			params.Name = fmt.Sprintf("concurrent-%d", i)
+			if err := RegisterGroup(id, &params); err != nil {
+				t.Errorf("%s: %v", t.Name(), err)
+			}
+			if _, err := GroupForGroupID(id); err != nil {
+				t.Errorf("%s: %v", t.Name(), err)
+			}
+			UnregisterGroup(id)
+		}(i)
+	}
+	wg.Wait()
+}
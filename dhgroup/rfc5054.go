@@ -0,0 +1,262 @@
+package dhgroup
+
+import "math/big"
+
+// Groups from RFC 5054 - https://tools.ietf.org/html/rfc5054#appendix-A.
+//
+// p is a safe prime, but unlike the RFC 3526 groups the generator g is a
+// quadratic non-residue and generates the whole group of order p-1.
+// To keep the prime-order subgroup of all groups, G = g^2 generates the
+// subgroup of order Q = (p-1)/2 and SRP uses g from SRPGenerator.
+// The 3072-bit and larger primes are the same as in RFC 3526.
+
+var srp1024 *GroupParams
+var srp1536 *GroupParams
+var srp2048 *GroupParams
+var srp3072 *GroupParams
+var srp4096 *GroupParams
+var srp6144 *GroupParams
+var srp8192 *GroupParams
+
+func initSRP1024() {
+	srp1024 = &GroupParams{Name: "SRP-1024"}
+	srp1024.P = bigFromBase16("EEAF0AB9ADB38DD69C33F80AFA8FC5E86072618775FF3C0B" +
+		"9EA2314C9C256576D674DF7496EA81D3383B4813D692C6E0" +
+		"E0D5D8E250B98BE48E495C1D6089DAD15DC7D7B46154D6B6" +
+		"CE8EF4AD69B15D4982559B297BCF1885C529F566660E57EC" +
+		"68EDBC3C05726CC02FD4CBF4976EAA9AFD5138FE8376435B" +
+		"9FC61D2FC0EB06E3")
+	srp1024.SRPGenerator = big.NewInt(2)
+	srp1024.G = new(big.Int).Exp(srp1024.SRPGenerator, big.NewInt(2), srp1024.P)
+	srp1024.Q = new(big.Int).Rsh(srp1024.P, 1)
+	srp1024.factors = []Factor{{big.NewInt(2), 1}}
+	srp1024.BitSize = 1024
+}
+
+func initSRP1536() {
+	srp1536 = &GroupParams{Name: "SRP-1536"}
+	srp1536.P = bigFromBase16("9DEF3CAFB939277AB1F12A8617A47BBBDBA51DF499AC4C80" +
+		"BEEEA9614B19CC4D5F4F5F556E27CBDE51C6A94BE4607A29" +
+		"1558903BA0D0F84380B655BB9A22E8DCDF028A7CEC67F0D0" +
+		"8134B1C8B97989149B609E0BE3BAB63D47548381DBC5B1FC" +
+		"764E3F4B53DD9DA1158BFD3E2B9C8CF56EDF019539349627" +
+		"DB2FD53D24B7C48665772E437D6C7F8CE442734AF7CCB7AE" +
+		"837C264AE3A9BEB87F8A2FE9B8B5292E5A021FFF5E91479E" +
+		"8CE7A28C2442C6F315180F93499A234DCF76E3FED135F9BB")
+	srp1536.SRPGenerator = big.NewInt(2)
+	srp1536.G = new(big.Int).Exp(srp1536.SRPGenerator, big.NewInt(2), srp1536.P)
+	srp1536.Q = new(big.Int).Rsh(srp1536.P, 1)
+	srp1536.factors = []Factor{{big.NewInt(2), 1}}
+	srp1536.BitSize = 1536
+}
+
+func initSRP2048() {
+	srp2048 = &GroupParams{Name: "SRP-2048"}
+	srp2048.P = bigFromBase16("AC6BDB41324A9A9BF166DE5E1389582FAF72B6651987EE07" +
+		"FC3192943DB56050A37329CBB4A099ED8193E0757767A13D" +
+		"D52312AB4B03310DCD7F48A9DA04FD50E8083969EDB767B0" +
+		"CF6095179A163AB3661A05FBD5FAAAE82918A9962F0B93B8" +
+		"55F97993EC975EEAA80D740ADBF4FF747359D041D5C33EA7" +
+		"1D281E446B14773BCA97B43A23FB801676BD207A436C6481" +
+		"F1D2B9078717461A5B9D32E688F87748544523B524B0D57D" +
+		"5EA77A2775D2ECFA032CFBDBF52FB3786160279004E57AE6" +
+		"AF874E7303CE53299CCC041C7BC308D82A5698F3A8D0C382" +
+		"71AE35F8E9DBFBB694B5C803D89F7AE435DE236D525F5475" +
+		"9B65E372FCD68EF20FA7111F9E4AFF73")
+	srp2048.SRPGenerator = big.NewInt(2)
+	srp2048.G = new(big.Int).Exp(srp2048.SRPGenerator, big.NewInt(2), srp2048.P)
+	srp2048.Q = new(big.Int).Rsh(srp2048.P, 1)
+	srp2048.factors = []Factor{{big.NewInt(2), 1}}
+	srp2048.BitSize = 2048
+}
+
+func initSRP3072() {
+	srp3072 = &GroupParams{Name: "SRP-3072"}
+	srp3072.P = bigFromBase16("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64" +
+		"ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6B" +
+		"F12FFA06D98A0864D87602733EC86A64521F2B18177B200C" +
+		"BBE117577A615D6C770988C0BAD946E208E24FA074E5AB31" +
+		"43DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF")
+	srp3072.SRPGenerator = big.NewInt(5)
+	srp3072.G = new(big.Int).Exp(srp3072.SRPGenerator, big.NewInt(2), srp3072.P)
+	srp3072.Q = new(big.Int).Rsh(srp3072.P, 1)
+	srp3072.factors = []Factor{{big.NewInt(2), 1}}
+	srp3072.BitSize = 3072
+}
+
+func initSRP4096() {
+	srp4096 = &GroupParams{Name: "SRP-4096"}
+	srp4096.P = bigFromBase16("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64" +
+		"ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6B" +
+		"F12FFA06D98A0864D87602733EC86A64521F2B18177B200C" +
+		"BBE117577A615D6C770988C0BAD946E208E24FA074E5AB31" +
+		"43DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D7" +
+		"88719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA" +
+		"2583E9CA2AD44CE8DBBBC2DB04DE8EF92E8EFC141FBECAA6" +
+		"287C59474E6BC05D99B2964FA090C3A2233BA186515BE7ED" +
+		"1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA9" +
+		"93B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934063199" +
+		"FFFFFFFFFFFFFFFF")
+	srp4096.SRPGenerator = big.NewInt(5)
+	srp4096.G = new(big.Int).Exp(srp4096.SRPGenerator, big.NewInt(2), srp4096.P)
+	srp4096.Q = new(big.Int).Rsh(srp4096.P, 1)
+	srp4096.factors = []Factor{{big.NewInt(2), 1}}
+	srp4096.BitSize = 4096
+}
+
+func initSRP6144() {
+	srp6144 = &GroupParams{Name: "SRP-6144"}
+	srp6144.P = bigFromBase16("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64" +
+		"ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6B" +
+		"F12FFA06D98A0864D87602733EC86A64521F2B18177B200C" +
+		"BBE117577A615D6C770988C0BAD946E208E24FA074E5AB31" +
+		"43DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D7" +
+		"88719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA" +
+		"2583E9CA2AD44CE8DBBBC2DB04DE8EF92E8EFC141FBECAA6" +
+		"287C59474E6BC05D99B2964FA090C3A2233BA186515BE7ED" +
+		"1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA9" +
+		"93B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934028492" +
+		"36C3FAB4D27C7026C1D4DCB2602646DEC9751E763DBA37BD" +
+		"F8FF9406AD9E530EE5DB382F413001AEB06A53ED9027D831" +
+		"179727B0865A8918DA3EDBEBCF9B14ED44CE6CBACED4BB1B" +
+		"DB7F1447E6CC254B332051512BD7AF426FB8F401378CD2BF" +
+		"5983CA01C64B92ECF032EA15D1721D03F482D7CE6E74FEF6" +
+		"D55E702F46980C82B5A84031900B1C9E59E7C97FBEC7E8F3" +
+		"23A97A7E36CC88BE0F1D45B7FF585AC54BD407B22B4154AA" +
+		"CC8F6D7EBF48E1D814CC5ED20F8037E0A79715EEF29BE328" +
+		"06A1D58BB7C5DA76F550AA3D8A1FBFF0EB19CCB1A313D55C" +
+		"DA56C9EC2EF29632387FE8D76E3C0468043E8F663F4860EE" +
+		"12BF2D5B0B7474D6E694F91E6DCC4024FFFFFFFFFFFFFFFF")
+	srp6144.SRPGenerator = big.NewInt(5)
+	srp6144.G = new(big.Int).Exp(srp6144.SRPGenerator, big.NewInt(2), srp6144.P)
+	srp6144.Q = new(big.Int).Rsh(srp6144.P, 1)
+	srp6144.factors = []Factor{{big.NewInt(2), 1}}
+	srp6144.BitSize = 6144
+}
+
+func initSRP8192() {
+	srp8192 = &GroupParams{Name: "SRP-8192"}
+	srp8192.P = bigFromBase16("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64" +
+		"ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6B" +
+		"F12FFA06D98A0864D87602733EC86A64521F2B18177B200C" +
+		"BBE117577A615D6C770988C0BAD946E208E24FA074E5AB31" +
+		"43DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D7" +
+		"88719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA" +
+		"2583E9CA2AD44CE8DBBBC2DB04DE8EF92E8EFC141FBECAA6" +
+		"287C59474E6BC05D99B2964FA090C3A2233BA186515BE7ED" +
+		"1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA9" +
+		"93B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934028492" +
+		"36C3FAB4D27C7026C1D4DCB2602646DEC9751E763DBA37BD" +
+		"F8FF9406AD9E530EE5DB382F413001AEB06A53ED9027D831" +
+		"179727B0865A8918DA3EDBEBCF9B14ED44CE6CBACED4BB1B" +
+		"DB7F1447E6CC254B332051512BD7AF426FB8F401378CD2BF" +
+		"5983CA01C64B92ECF032EA15D1721D03F482D7CE6E74FEF6" +
+		"D55E702F46980C82B5A84031900B1C9E59E7C97FBEC7E8F3" +
+		"23A97A7E36CC88BE0F1D45B7FF585AC54BD407B22B4154AA" +
+		"CC8F6D7EBF48E1D814CC5ED20F8037E0A79715EEF29BE328" +
+		"06A1D58BB7C5DA76F550AA3D8A1FBFF0EB19CCB1A313D55C" +
+		"DA56C9EC2EF29632387FE8D76E3C0468043E8F663F4860EE" +
+		"12BF2D5B0B7474D6E694F91E6DBE115974A3926F12FEE5E4" +
+		"38777CB6A932DF8CD8BEC4D073B931BA3BC832B68D9DD300" +
+		"741FA7BF8AFC47ED2576F6936BA424663AAB639C5AE4F568" +
+		"3423B4742BF1C978238F16CBE39D652DE3FDB8BEFC848AD9" +
+		"22222E04A4037C0713EB57A81A23F0C73473FC646CEA306B" +
+		"4BCBC8862F8385DDFA9D4B7FA2C087E879683303ED5BDD3A" +
+		"062B3CF5B3A278A66D2A13F83F44F82DDF310EE074AB6A36" +
+		"4597E899A0255DC164F31CC50846851DF9AB48195DED7EA1" +
+		"B1D510BD7EE74D73FAF36BC31ECFA268359046F4EB879F92" +
+		"4009438B481C6CD7889A002ED5EE382BC9190DA6FC026E47" +
+		"9558E4475677E9AA9E3050E2765694DFC81F56E880B96E71" +
+		"60C980DD98EDD3DFFFFFFFFFFFFFFFFF")
+	srp8192.SRPGenerator = big.NewInt(19)
+	srp8192.G = new(big.Int).Exp(srp8192.SRPGenerator, big.NewInt(2), srp8192.P)
+	srp8192.Q = new(big.Int).Rsh(srp8192.P, 1)
+	srp8192.factors = []Factor{{big.NewInt(2), 1}}
+	srp8192.BitSize = 8192
+}
+
+// SRP1024 returns the 1024-bit group from RFC 5054.
+func SRP1024() DHScheme {
+	initonce.Do(initAll)
+	return srp1024
+}
+
+// SRP1536 returns the 1536-bit group from RFC 5054.
+func SRP1536() DHScheme {
+	initonce.Do(initAll)
+	return srp1536
+}
+
+// SRP2048 returns the 2048-bit group from RFC 5054.
+func SRP2048() DHScheme {
+	initonce.Do(initAll)
+	return srp2048
+}
+
+// SRP3072 returns the 3072-bit group from RFC 5054.
+func SRP3072() DHScheme {
+	initonce.Do(initAll)
+	return srp3072
+}
+
+// SRP4096 returns the 4096-bit group from RFC 5054.
+func SRP4096() DHScheme {
+	initonce.Do(initAll)
+	return srp4096
+}
+
+// SRP6144 returns the 6144-bit group from RFC 5054.
+func SRP6144() DHScheme {
+	initonce.Do(initAll)
+	return srp6144
+}
+
+// SRP8192 returns the 8192-bit group from RFC 5054.
+func SRP8192() DHScheme {
+	initonce.Do(initAll)
+	return srp8192
+}
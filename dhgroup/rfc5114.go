@@ -0,0 +1,135 @@
+package dhgroup
+
+import "math/big"
+
+// Groups from RFC 5114 - https://tools.ietf.org/html/rfc5114.
+//
+// Unlike the RFC 3526 groups, p is not a safe prime: the cofactor (p-1)/Q is
+// large and has a number of small prime factors, so these groups are exposed
+// to small-subgroup confinement unless public keys are validated.
+// See Valenta et al., "Measuring small subgroup attacks against Diffie-Hellman".
+
+var modp1024s160 *GroupParams
+var modp2048s224 *GroupParams
+var modp2048s256 *GroupParams
+
+func initMODP1024S160() {
+	modp1024s160 = &GroupParams{Name: "MODP-1024-160"}
+	modp1024s160.P = bigFromBase16("B10B8F96A080E01DDE92DE5EAE5D54EC52C99FBCFB06A3C6" +
+		"9A6A9DCA52D23B616073E28675A23D189838EF1E2EE652C0" +
+		"13ECB4AEA906112324975C3CD49B83BFACCBDD7D90C4BD70" +
+		"98488E9C219A73724EFFD6FAE5644738FAA31A4FF55BCCC0" +
+		"A151AF5F0DC8B4BD45BF37DF365C1A65E68CFDA76D4DA708" +
+		"DF1FB2BC2E4A4371")
+	modp1024s160.G = bigFromBase16("A4D1CBD5C3FD34126765A442EFB99905F8104DD258AC507F" +
+		"D6406CFF14266D31266FEA1E5C41564B777E690F5504F213" +
+		"160217B4B01B886A5E91547F9E2749F4D7FBD7D3B9A92EE1" +
+		"909D0D2263F80A76A6A24C087A091F531DBF0A0169B6A28A" +
+		"D662A4D18E73AFA32D779D5918D08BC8858F4DCEF97C2A24" +
+		"855E6EEB22B3B2E5")
+	modp1024s160.Q = bigFromBase16("F518AA8781A8DF278ABA4E7D64B7CB9D49462353")
+	modp1024s160.BitSize = 1024
+	modp1024s160.factors = []Factor{
+		{big.NewInt(2), 4},
+		{big.NewInt(7), 1},
+		{big.NewInt(223), 1},
+	}
+}
+
+func initMODP2048S224() {
+	modp2048s224 = &GroupParams{Name: "MODP-2048-224"}
+	modp2048s224.P = bigFromBase16("AD107E1E9123A9D0D660FAA79559C51FA20D64E5683B9FD1" +
+		"B54B1597B61D0A75E6FA141DF95A56DBAF9A3C407BA1DF15" +
+		"EB3D688A309C180E1DE6B85A1274A0A66D3F8152AD6AC212" +
+		"9037C9EDEFDA4DF8D91E8FEF55B7394B7AD5B7D0B6C12207" +
+		"C9F98D11ED34DBF6C6BA0B2C8BBC27BE6A00E0A0B9C49708" +
+		"B3BF8A317091883681286130BC8985DB1602E714415D9330" +
+		"278273C7DE31EFDC7310F7121FD5A07415987D9ADC0A486D" +
+		"CDF93ACC44328387315D75E198C641A480CD86A1B9E587E8" +
+		"BE60E69CC928B2B9C52172E413042E9B23F10B0E16E79763" +
+		"C9B53DCF4BA80A29E3FB73C16B8E75B97EF363E2FFA31F71" +
+		"CF9DE5384E71B81C0AC4DFFE0C10E64F")
+	modp2048s224.G = bigFromBase16("AC4032EF4F2D9AE39DF30B5C8FFDAC506CDEBE7B89998CAF" +
+		"74866A08CFE4FFE3A6824A4E10B9A6F0DD921F01A70C4AFA" +
+		"AB739D7700C29F52C57DB17C620A8652BE5E9001A8D66AD7" +
+		"C17669101999024AF4D027275AC1348BB8A762D0521BC98A" +
+		"E247150422EA1ED409939D54DA7460CDB5F6C6B250717CBE" +
+		"F180EB34118E98D119529A45D6F834566E3025E316A330EF" +
+		"BB77A86F0C1AB15B051AE3D428C8F8ACB70A8137150B8EEB" +
+		"10E183EDD19963DDD9E263E4770589EF6AA21E7F5F2FF381" +
+		"B539CCE3409D13CD566AFBB48D6C019181E1BCFE94B30269" +
+		"EDFE72FE9B6AA4BD7B5A0F1C71CFFF4C19C418E1F6EC0179" +
+		"81BC087F2A7065B384B890D3191F2BFA")
+	modp2048s224.Q = bigFromBase16("801C0D34C58D93FE997177101F80535A4738CEBCBF389A99" +
+		"B36371EB")
+	modp2048s224.BitSize = 2048
+	modp2048s224.factors = []Factor{
+		{big.NewInt(2), 1},
+		{big.NewInt(3), 2},
+		{big.NewInt(5), 1},
+		{big.NewInt(43), 1},
+		{big.NewInt(73), 1},
+		{big.NewInt(157), 1},
+		{big.NewInt(387493), 1},
+		{big.NewInt(605921), 1},
+		{big.NewInt(742327609), 1},
+		{big.NewInt(5213881177), 1},
+		{big.NewInt(112486462861), 1},
+	}
+}
+
+func initMODP2048S256() {
+	modp2048s256 = &GroupParams{Name: "MODP-2048-256"}
+	modp2048s256.P = bigFromBase16("87A8E61DB4B6663CFFBBD19C651959998CEEF608660DD0F2" +
+		"5D2CEED4435E3B00E00DF8F1D61957D4FAF7DF4561B2AA30" +
+		"16C3D91134096FAA3BF4296D830E9A7C209E0C6497517ABD" +
+		"5A8A9D306BCF67ED91F9E6725B4758C022E0B1EF4275BF7B" +
+		"6C5BFC11D45F9088B941F54EB1E59BB8BC39A0BF12307F5C" +
+		"4FDB70C581B23F76B63ACAE1CAA6B7902D52526735488A0E" +
+		"F13C6D9A51BFA4AB3AD8347796524D8EF6A167B5A41825D9" +
+		"67E144E5140564251CCACB83E6B486F6B3CA3F7971506026" +
+		"C0B857F689962856DED4010ABD0BE621C3A3960A54E710C3" +
+		"75F26375D7014103A4B54330C198AF126116D2276E11715F" +
+		"693877FAD7EF09CADB094AE91E1A1597")
+	modp2048s256.G = bigFromBase16("3FB32C9B73134D0B2E77506660EDBD484CA7B18F21EF2054" +
+		"07F4793A1A0BA12510DBC15077BE463FFF4FED4AAC0BB555" +
+		"BE3A6C1B0C6B47B1BC3773BF7E8C6F62901228F8C28CBB18" +
+		"A55AE31341000A650196F931C77A57F2DDF463E5E9EC144B" +
+		"777DE62AAAB8A8628AC376D282D6ED3864E67982428EBC83" +
+		"1D14348F6F2F9193B5045AF2767164E1DFC967C1FB3F2E55" +
+		"A4BD1BFFE83B9C80D052B985D182EA0ADB2A3B7313D3FE14" +
+		"C8484B1E052588B9B7D2BBD2DF016199ECD06E1557CD0915" +
+		"B3353BBB64E0EC377FD028370DF92B52C7891428CDC67EB6" +
+		"184B523D1DB246C32F63078490F00EF8D647D148D4795451" +
+		"5E2327CFEF98C582664B4C0F6CC41659")
+	modp2048s256.Q = bigFromBase16("8CF83642A709A097B447997640129DA299B1A47D1EB3750B" +
+		"A308B0FE64F5FBD3")
+	modp2048s256.BitSize = 2048
+	modp2048s256.factors = []Factor{
+		{big.NewInt(2), 1},
+		{big.NewInt(7), 1},
+		{big.NewInt(13), 1},
+		{big.NewInt(2549), 1},
+		{big.NewInt(142031), 1},
+		{big.NewInt(3181327537), 1},
+		{bigFromBase10("1281283182883005470598494883002419975584726457228346869043162639345125278077574324851053375540172220404754058473574190034619431822799682024150179737801522531751509335567218421019403887940667549844008983050071290403819711162761412014727493416025611660522911398981260094011065339636593376270498200295967321284313940062847146763469893291735327749979736067385681963051963424764671079433865702364231528240678123841918961039558992906107653130650848239970090252276068067190187709449024831046922638556560997380409772574889108321"), 1},
+	}
+}
+
+// MODP1024S160 returns the 1024-bit MODP group with 160-bit prime order subgroup from RFC 5114.
+func MODP1024S160() DHScheme {
+	initonce.Do(initAll)
+	return modp1024s160
+}
+
+// MODP2048S224 returns the 2048-bit MODP group with 224-bit prime order subgroup from RFC 5114.
+func MODP2048S224() DHScheme {
+	initonce.Do(initAll)
+	return modp2048s224
+}
+
+// MODP2048S256 returns the 2048-bit MODP group with 256-bit prime order subgroup from RFC 5114.
+func MODP2048S256() DHScheme {
+	initonce.Do(initAll)
+	return modp2048s256
+}
@@ -0,0 +1,277 @@
+package dhgroup
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	_ "crypto/sha256" // the default SRP hash
+	"errors"
+	"io"
+	"math/big"
+)
+
+// SRP implements the SRP-6a password-authenticated key exchange
+// (RFC 2945, RFC 5054) over a DH group, usually one of the RFC 5054 groups:
+//
+//	Client -> Server: I, A = g^a
+//	Server -> Client: s, B = k*v + g^b
+//	Client -> Server: M1 = H(H(N) xor H(g), H(I), s, A, B, K)
+//	Server -> Client: M2 = H(A, M1, K)
+//
+// where k = H(N, PAD(g)), x = H(s, H(I ":" P)), v = g^x, u = H(PAD(A), PAD(B))
+// and K = H(S) for the premaster secret S = (B - k*g^x)^(a + u*x) = (A*v^u)^b.
+type SRP struct {
+	Group DHScheme
+
+	// Hash is the hash function H. Zero means SHA-256.
+	Hash crypto.Hash
+}
+
+// SRPVerifier is the password verifier the server stores for a user.
+type SRPVerifier struct {
+	Identity string
+	Salt     []byte
+	V        *big.Int
+}
+
+var (
+	// ErrSRPInvalidPublicKey is returned when A mod N = 0 or B mod N = 0.
+	ErrSRPInvalidPublicKey = errors.New("dhgroup: invalid SRP public key")
+
+	// ErrSRPAuthFailed is returned when the peer's proof is wrong.
+	ErrSRPAuthFailed = errors.New("dhgroup: SRP authentication failed")
+)
+
+// srpSaltLen is the length of generated salts in bytes.
+const srpSaltLen = 16
+
+func (srp *SRP) hash(parts ...[]byte) []byte {
+	h := srp.Hash
+	if h == 0 {
+		h = crypto.SHA256
+	}
+	d := h.New()
+	for _, p := range parts {
+		d.Write(p)
+	}
+	return d.Sum(nil)
+}
+
+func (srp *SRP) hashInt(parts ...[]byte) *big.Int {
+	return new(big.Int).SetBytes(srp.hash(parts...))
+}
+
+// pad encodes n with the length of N.
+func (srp *SRP) pad(n *big.Int) []byte {
+	return SharedSecretBytes(srp.Group, n)
+}
+
+// multiplier returns k = H(N, PAD(g)).
+func (srp *SRP) multiplier() *big.Int {
+	params := srp.Group.DHParams()
+	return srp.hashInt(params.P.Bytes(), srp.pad(srp.generator()))
+}
+
+// generator returns the SRP generator g, which is SRPGenerator if the group sets it and G otherwise.
+func (srp *SRP) generator() *big.Int {
+	params := srp.Group.DHParams()
+	if params.SRPGenerator != nil {
+		return params.SRPGenerator
+	}
+	return params.G
+}
+
+// scrambler returns u = H(PAD(A), PAD(B)).
+func (srp *SRP) scrambler(A, B *big.Int) *big.Int {
+	return srp.hashInt(srp.pad(A), srp.pad(B))
+}
+
+// privateKey returns x = H(s, H(I ":" P)).
+func (srp *SRP) privateKey(identity string, salt, password []byte) *big.Int {
+	inner := srp.hash([]byte(identity), []byte(":"), password)
+	return srp.hashInt(salt, inner)
+}
+
+// SessionKey returns the session key K = H(S) for the premaster secret S.
+func (srp *SRP) SessionKey(S *big.Int) []byte {
+	return srp.hash(S.Bytes())
+}
+
+// ClientProof returns M1 = H(H(N) xor H(g), H(I), s, A, B, K).
+func (srp *SRP) ClientProof(identity string, salt []byte, A, B *big.Int, key []byte) []byte {
+	params := srp.Group.DHParams()
+	hn := srp.hash(params.P.Bytes())
+	hg := srp.hash(srp.generator().Bytes())
+	for i := range hn {
+		hn[i] ^= hg[i]
+	}
+	return srp.hash(hn, srp.hash([]byte(identity)), salt, A.Bytes(), B.Bytes(), key)
+}
+
+// ServerProof returns M2 = H(A, M1, K).
+func (srp *SRP) ServerProof(A *big.Int, m1, key []byte) []byte {
+	return srp.hash(A.Bytes(), m1, key)
+}
+
+// NewVerifier generates a random salt and computes the verifier v = g^x for the password.
+func (srp *SRP) NewVerifier(rng io.Reader, identity string, password []byte) (*SRPVerifier, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	salt := make([]byte, srpSaltLen)
+	if _, err := io.ReadFull(rng, salt); err != nil {
+		return nil, err
+	}
+	return srp.newVerifier(identity, salt, password), nil
+}
+
+func (srp *SRP) newVerifier(identity string, salt, password []byte) *SRPVerifier {
+	params := srp.Group.DHParams()
+	x := srp.privateKey(identity, salt, password)
+	return &SRPVerifier{
+		Identity: identity,
+		Salt:     salt,
+		V:        new(big.Int).Exp(srp.generator(), x, params.P),
+	}
+}
+
+// SRPClient is the client side of an SRP-6a session.
+type SRPClient struct {
+	srp      *SRP
+	identity string
+	password []byte
+	a        *big.Int
+	m1, key  []byte
+
+	// A is the client's public key sent with the identity.
+	A *big.Int
+}
+
+// NewClient starts a session for the identity and the password.
+func (srp *SRP) NewClient(rng io.Reader, identity string, password []byte) (*SRPClient, error) {
+	key, err := srp.Group.GenerateKey(rng)
+	if err != nil {
+		return nil, err
+	}
+	return srp.newClient(identity, password, key.Private), nil
+}
+
+func (srp *SRP) newClient(identity string, password []byte, a *big.Int) *SRPClient {
+	params := srp.Group.DHParams()
+	return &SRPClient{
+		srp:      srp,
+		identity: identity,
+		password: password,
+		a:        a,
+		A:        new(big.Int).Exp(srp.generator(), a, params.P),
+	}
+}
+
+// Proof processes the server's salt and public key and returns the client's proof M1.
+func (c *SRPClient) Proof(salt []byte, B *big.Int) ([]byte, error) {
+	srp := c.srp
+	p := srp.Group.DHParams().P
+	if new(big.Int).Mod(B, p).Sign() == 0 {
+		return nil, ErrSRPInvalidPublicKey
+	}
+	u := srp.scrambler(c.A, B)
+	if u.Sign() == 0 {
+		return nil, ErrSRPInvalidPublicKey
+	}
+	x := srp.privateKey(c.identity, salt, c.password)
+
+	// S = (B - k*g^x)^(a + u*x)
+	base := new(big.Int).Exp(srp.generator(), x, p)
+	base.Mul(base, srp.multiplier())
+	base.Sub(B, base)
+	base.Mod(base, p)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.a)
+	S := base.Exp(base, exp, p)
+
+	c.key = srp.SessionKey(S)
+	c.m1 = srp.ClientProof(c.identity, salt, c.A, B, c.key)
+	return c.m1, nil
+}
+
+// VerifyServer reports whether m2 is the correct server proof.
+func (c *SRPClient) VerifyServer(m2 []byte) bool {
+	if c.key == nil {
+		return false
+	}
+	return hmac.Equal(m2, c.srp.ServerProof(c.A, c.m1, c.key))
+}
+
+// Key returns the session key K; it is nil before Proof.
+func (c *SRPClient) Key() []byte {
+	return c.key
+}
+
+// SRPServer is the server side of an SRP-6a session.
+type SRPServer struct {
+	srp      *SRP
+	verifier *SRPVerifier
+	b        *big.Int
+	key      []byte
+
+	// B is the server's public key sent with the salt.
+	B *big.Int
+
+	// NoPublicKeyCheck disables the check A mod N != 0. Without it,
+	// a client sending A = 0 or A = k*N logs in without the password.
+	NoPublicKeyCheck bool
+}
+
+// NewServer starts a session for the user with the given verifier.
+func (srp *SRP) NewServer(rng io.Reader, verifier *SRPVerifier) (*SRPServer, error) {
+	key, err := srp.Group.GenerateKey(rng)
+	if err != nil {
+		return nil, err
+	}
+	return srp.newServer(verifier, key.Private), nil
+}
+
+func (srp *SRP) newServer(verifier *SRPVerifier, b *big.Int) *SRPServer {
+	params := srp.Group.DHParams()
+
+	// B = k*v + g^b
+	B := new(big.Int).Mul(srp.multiplier(), verifier.V)
+	B.Add(B, new(big.Int).Exp(srp.generator(), b, params.P))
+	B.Mod(B, params.P)
+	return &SRPServer{srp: srp, verifier: verifier, b: b, B: B}
+}
+
+// Salt returns the salt of the user.
+func (s *SRPServer) Salt() []byte {
+	return s.verifier.Salt
+}
+
+// premasterSecret returns S = (A*v^u)^b.
+func (s *SRPServer) premasterSecret(A *big.Int) *big.Int {
+	p := s.srp.Group.DHParams().P
+	u := s.srp.scrambler(A, s.B)
+	S := new(big.Int).Exp(s.verifier.V, u, p)
+	S.Mul(S, A)
+	S.Mod(S, p)
+	return S.Exp(S, s.b, p)
+}
+
+// Verify checks the client's public key and proof and returns the server proof M2.
+func (s *SRPServer) Verify(A *big.Int, m1 []byte) ([]byte, error) {
+	srp := s.srp
+	if !s.NoPublicKeyCheck && new(big.Int).Mod(A, srp.Group.DHParams().P).Sign() == 0 {
+		return nil, ErrSRPInvalidPublicKey
+	}
+
+	key := srp.SessionKey(s.premasterSecret(A))
+	if !hmac.Equal(m1, srp.ClientProof(s.verifier.Identity, s.verifier.Salt, A, s.B, key)) {
+		return nil, ErrSRPAuthFailed
+	}
+	s.key = key
+	return srp.ServerProof(A, m1, key), nil
+}
+
+// Key returns the session key K; it is nil until the client is authenticated.
+func (s *SRPServer) Key() []byte {
+	return s.key
+}
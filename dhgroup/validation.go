@@ -0,0 +1,91 @@
+package dhgroup
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ValidationPolicy defines how DH checks a peer public key before using it.
+type ValidationPolicy int
+
+const (
+	// NoValidation accepts any public key as is.
+	NoValidation ValidationPolicy = iota
+	// RangeCheck requires 1 < y < p-1.
+	RangeCheck
+	// SubgroupCheck requires 1 < y < p-1 and y^Q = 1 mod p.
+	SubgroupCheck
+	// CofactorClearing raises y to the cofactor h = (p-1)/Q before the exponentiation
+	// and rejects the result if it collapses to 1. DH then returns g^(abh) instead of
+	// g^(ab), so both parties must use this policy to agree on the shared secret.
+	CofactorClearing
+)
+
+var (
+	// ErrPublicKeyOutOfRange is returned when a public key is not in (1, p-1).
+	ErrPublicKeyOutOfRange = errors.New("dhgroup: public key is out of range")
+	// ErrPublicKeyNotInSubgroup is returned when a public key is not in the subgroup of order Q.
+	ErrPublicKeyNotInSubgroup = errors.New("dhgroup: public key is not in the prime-order subgroup")
+	// ErrSmallOrderSharedSecret is returned when cofactor clearing maps a public key or a shared secret to 1.
+	ErrSmallOrderSharedSecret = errors.New("dhgroup: public key has small order")
+)
+
+func (v ValidationPolicy) String() string {
+	switch v {
+	case NoValidation:
+		return "none"
+	case RangeCheck:
+		return "range"
+	case SubgroupCheck:
+		return "subgroup"
+	case CofactorClearing:
+		return "cofactor"
+	}
+	return fmt.Sprintf("ValidationPolicy(%d)", int(v))
+}
+
+// WithValidation returns a copy of the group that validates peer public keys using policy.
+func WithValidation(group DHScheme, policy ValidationPolicy) DHScheme {
+	g := *group.DHParams()
+	g.Validation = policy
+	return &g
+}
+
+// validate checks the public key according to the group policy and returns
+// the value that must be used in the exponentiation.
+func (g GroupParams) validate(y *big.Int) (*big.Int, error) {
+	switch g.Validation {
+	case NoValidation:
+		return y, nil
+	case RangeCheck:
+		if !g.inRange(y) {
+			return nil, ErrPublicKeyOutOfRange
+		}
+		return y, nil
+	case SubgroupCheck:
+		if !g.inRange(y) {
+			return nil, ErrPublicKeyOutOfRange
+		}
+		if new(big.Int).Exp(y, g.Q, g.P).Cmp(big.NewInt(1)) != 0 {
+			return nil, ErrPublicKeyNotInSubgroup
+		}
+		return y, nil
+	case CofactorClearing:
+		if !g.inRange(y) {
+			return nil, ErrPublicKeyOutOfRange
+		}
+		cy := new(big.Int).Exp(y, g.Cofactor(), g.P)
+		if cy.Cmp(big.NewInt(1)) == 0 {
+			return nil, ErrSmallOrderSharedSecret
+		}
+		return cy, nil
+	}
+	return nil, fmt.Errorf("dhgroup: unknown validation policy %d", g.Validation)
+}
+
+// inRange reports whether 1 < y < p-1.
+func (g GroupParams) inRange(y *big.Int) bool {
+	pm1 := new(big.Int).Sub(g.P, big.NewInt(1))
+	return y.Cmp(big.NewInt(1)) > 0 && y.Cmp(pm1) < 0
+}
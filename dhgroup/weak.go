@@ -0,0 +1,159 @@
+package dhgroup
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+)
+
+// WeakGroupConfig describes a group generated by GenerateWeakGroup.
+type WeakGroupConfig struct {
+	// QBits is the bit length of the prime order Q of the subgroup generated by G.
+	QBits int
+	// SmoothnessBound is the exclusive upper bound of the odd prime factors of the cofactor.
+	SmoothnessBound int64
+	// SmallFactors is the number of distinct odd prime factors of the cofactor.
+	SmallFactors int
+	// MaxAttempts limits the number of cofactors tried for a single Q.
+	// Zero means 10000.
+	MaxAttempts int
+}
+
+// GenerateWeakGroup generates a group with a prime p = Q*cofactor + 1, where
+// cofactor = 2*r_1*...*r_n and r_i are distinct primes less than the smoothness bound.
+// Such groups are vulnerable to small-subgroup confinement attacks.
+//
+// It returns the group and the complete factorization of the cofactor.
+// The generation is deterministic for a given source of randomness,
+// so a seeded reader (e.g. math/rand.New(math/rand.NewSource(seed))) can be
+// used to reproduce a group.
+func GenerateWeakGroup(rng io.Reader, cfg WeakGroupConfig) (*GroupParams, []Factor, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	if cfg.QBits < 2 {
+		return nil, nil, errors.New("dhgroup: Q must be at least 2 bits long")
+	}
+	if cfg.SmallFactors < 0 {
+		return nil, nil, errors.New("dhgroup: negative number of small factors")
+	}
+	if cfg.SmallFactors > 0 && cfg.SmoothnessBound < 4 {
+		return nil, nil, errors.New("dhgroup: smoothness bound must be greater than 3")
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 10000
+	}
+
+	q, err := randomPrime(rng, cfg.QBits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bound := big.NewInt(cfg.SmoothnessBound)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		factors := []Factor{{big.NewInt(2), 1}}
+		cofactor := big.NewInt(2)
+		for tries := 0; len(factors) < cfg.SmallFactors+1; tries++ {
+			if tries > 100*cfg.SmallFactors {
+				return nil, nil, errors.New("dhgroup: not enough small primes below the smoothness bound")
+			}
+			r, err := randomPrimeBelow(rng, bound)
+			if err != nil {
+				return nil, nil, err
+			}
+			if r.Cmp(q) == 0 || hasFactor(factors, r) {
+				continue
+			}
+			factors = append(factors, Factor{r, 1})
+			cofactor.Mul(cofactor, r)
+		}
+
+		p := new(big.Int).Mul(q, cofactor)
+		p.Add(p, big.NewInt(1))
+		if !p.ProbablyPrime(20) {
+			continue
+		}
+
+		g, err := subgroupGenerator(rng, p, cofactor)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sort.Slice(factors, func(i, j int) bool {
+			return factors[i].Prime.Cmp(factors[j].Prime) < 0
+		})
+
+		group := &GroupParams{
+			P:       p,
+			G:       g,
+			Q:       q,
+			Name:    fmt.Sprintf("WEAK-%d-%d", p.BitLen(), cfg.QBits),
+			BitSize: p.BitLen(),
+			factors: factors,
+		}
+		group.base = newFixedBase(g, p, q.BitLen())
+		factorization, _ := group.CofactorFactors()
+		return group, factorization, nil
+	}
+	return nil, nil, errors.New("dhgroup: failed to generate a weak group")
+}
+
+// randomPrime returns a prime of exactly the given bit length.
+// Unlike crypto/rand.Prime, it only reads from rng, so the result is
+// reproducible for a deterministic source.
+func randomPrime(rng io.Reader, bits int) (*big.Int, error) {
+	max := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+	for {
+		p, err := rand.Int(rng, max)
+		if err != nil {
+			return nil, err
+		}
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, 0, 1)
+		if p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
+}
+
+// randomPrimeBelow returns an odd prime in [3, max).
+func randomPrimeBelow(rng io.Reader, max *big.Int) (*big.Int, error) {
+	for {
+		r, err := rand.Int(rng, max)
+		if err != nil {
+			return nil, err
+		}
+		if r.Cmp(big.NewInt(3)) >= 0 && r.Bit(0) == 1 && r.ProbablyPrime(20) {
+			return r, nil
+		}
+	}
+}
+
+// subgroupGenerator returns a generator of the subgroup of order (p-1)/cofactor.
+func subgroupGenerator(rng io.Reader, p, cofactor *big.Int) (*big.Int, error) {
+	max := new(big.Int).Sub(p, big.NewInt(3))
+	for {
+		h, err := rand.Int(rng, max)
+		if err != nil {
+			return nil, err
+		}
+		h.Add(h, big.NewInt(2))
+		g := h.Exp(h, cofactor, p)
+		if g.Cmp(big.NewInt(1)) != 0 {
+			return g, nil
+		}
+	}
+}
+
+func hasFactor(factors []Factor, r *big.Int) bool {
+	for _, f := range factors {
+		if f.Prime.Cmp(r) == 0 {
+			return true
+		}
+	}
+	return false
+}
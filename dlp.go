@@ -36,6 +36,142 @@ func crt(a, n []*big.Int) (*big.Int, *big.Int, error) {
 	return x.Mod(&x, p), p, nil
 }
 
+// crtMerge combines x = a1 mod n1 and x = a2 mod n2 for moduli that are not necessarily
+// coprime. Let d = gcd(n1, n2). The system has a solution iff a1 = a2 mod d, and then
+// x = a1 + n1*t, where t = (a2 - a1)/d * (n1/d)^-1 mod n2/d, is unique modulo lcm(n1, n2).
+func crtMerge(a1, n1, a2, n2 *big.Int) (*big.Int, *big.Int, error) {
+	d := new(big.Int).GCD(nil, nil, n1, n2)
+	diff := new(big.Int).Sub(a2, a1)
+	if !divides(d, diff) {
+		return nil, nil, fmt.Errorf("%d mod %d and %d mod %d are inconsistent", a1, n1, a2, n2)
+	}
+	n2d := new(big.Int).Div(n2, d)
+	t := new(big.Int).Div(diff, d)
+	if n2d.Cmp(Big1) == 0 {
+		t.SetInt64(0)
+	} else {
+		t.Mul(t, new(big.Int).ModInverse(new(big.Int).Div(n1, d), n2d))
+		t.Mod(t, n2d)
+	}
+
+	m := new(big.Int).Mul(n1, n2d)
+	x := t.Mul(t, n1)
+	x.Add(x, a1)
+	return x.Mod(x, m), m, nil
+}
+
+// crtGeneralized works as crt, but the moduli may have common factors.
+// It returns the solution modulo N = lcm(n_1, ..., n_m) or an error if the equations are inconsistent.
+func crtGeneralized(a, n []*big.Int) (*big.Int, *big.Int, error) {
+	if len(a) != len(n) || len(n) == 0 {
+		return nil, nil, errors.New("crt: invalid number of equations")
+	}
+	x, m := new(big.Int).Mod(a[0], n[0]), new(big.Int).Set(n[0])
+	for i := 1; i < len(n); i++ {
+		var err error
+		x, m, err = crtMerge(x, m, a[i], n[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return x, m, nil
+}
+
+// crtCandidates enumerates the solutions of x = a_i mod n_i, where every a_i is one of
+// several candidate residues, e.g. ±k when only the x-coordinate of k*P is known.
+//
+// The solutions are found lazily by depth-first search over the moduli in the given
+// order. After every merged equation the verifier is called with the partial solution
+// x mod M, where M is the lcm of the moduli merged so far, so a wrong combination is
+// rejected before the other moduli multiply the number of candidates. Moduli with fewer
+// candidates or cheap checks should go first.
+type crtCandidates struct {
+	residues [][]*big.Int
+	moduli   []*big.Int
+	verify   func(x, m *big.Int) bool
+
+	// level is the number of merged equations and choice[i] is the index of the next
+	// candidate of the i-th equation. xs[i] mod ms[i] is the solution of the first i equations.
+	level  int
+	choice []int
+	xs, ms []*big.Int
+
+	// Rejected is the number of partial solutions rejected by the verifier or
+	// because of inconsistent equations.
+	Rejected int
+}
+
+// newCRTCandidates returns the enumerator of the solutions. verify may be nil.
+func newCRTCandidates(residues [][]*big.Int, n []*big.Int, verify func(x, m *big.Int) bool) (*crtCandidates, error) {
+	if len(residues) != len(n) || len(n) == 0 {
+		return nil, errors.New("crt: invalid number of equations")
+	}
+	c := &crtCandidates{
+		moduli: n,
+		verify: verify,
+		choice: make([]int, len(n)),
+		xs:     make([]*big.Int, len(n)+1),
+		ms:     make([]*big.Int, len(n)+1),
+	}
+	// Equal candidates would give the same solutions twice.
+	for i, rs := range residues {
+		seen := make(map[string]bool)
+		var unique []*big.Int
+		for _, r := range rs {
+			r = new(big.Int).Mod(r, n[i])
+			if !seen[r.String()] {
+				seen[r.String()] = true
+				unique = append(unique, r)
+			}
+		}
+		c.residues = append(c.residues, unique)
+	}
+	c.xs[0], c.ms[0] = new(big.Int), big.NewInt(1)
+	return c, nil
+}
+
+// next returns the next solution modulo lcm(n_1, ..., n_m) accepted by the verifier.
+// It returns false when there are no more solutions.
+func (c *crtCandidates) next() (*big.Int, bool) {
+	for c.level >= 0 {
+		k := c.level
+		if k == len(c.moduli) {
+			// The solution was returned by the previous call.
+			c.level--
+			continue
+		}
+		if c.choice[k] == len(c.residues[k]) {
+			c.choice[k] = 0
+			c.level--
+			continue
+		}
+		r := c.residues[k][c.choice[k]]
+		c.choice[k]++
+
+		x, m, err := crtMerge(c.xs[k], c.ms[k], r, c.moduli[k])
+		if err != nil || (c.verify != nil && !c.verify(x, m)) {
+			c.Rejected++
+			continue
+		}
+		c.xs[k+1], c.ms[k+1] = x, m
+		c.level++
+		if c.level == len(c.moduli) {
+			return new(big.Int).Set(x), true
+		}
+	}
+	return nil, false
+}
+
+// modulus returns lcm(n_1, ..., n_m).
+func (c *crtCandidates) modulus() *big.Int {
+	m := big.NewInt(1)
+	for _, n := range c.moduli {
+		d := new(big.Int).GCD(nil, nil, m, n)
+		m.Mul(m, new(big.Int).Div(n, d))
+	}
+	return m
+}
+
 // divides returns true if x divides y.
 func divides(x, y *big.Int) bool {
 	return new(big.Int).Mod(y, x).Cmp(Big0) == 0
@@ -83,8 +219,16 @@ func bsgs(g, y, p *big.Int) (*big.Int, error) {
 	if g.Cmp(Big0) == 0 {
 		return nil, errors.New("no solution in bsgs")
 	}
-	totient := phi(p)
-	m := new(big.Int).Sqrt(totient)
+	return bsgsOrder(g, y, p, phi(p))
+}
+
+// bsgsOrder works as bsgs, but takes the order of g (or its multiple) instead of
+// computing phi(p), which is infeasible for large p.
+func bsgsOrder(g, y, p, n *big.Int) (*big.Int, error) {
+	if g.Cmp(Big0) == 0 {
+		return nil, errors.New("no solution in bsgs")
+	}
+	m := new(big.Int).Sqrt(n)
 	m.Add(m, Big1)
 	state := make(map[string]*big.Int)
 
@@ -146,7 +290,13 @@ func pohligHellman(g, y, p *big.Int) *big.Int {
 	var N, A []*big.Int
 	n := phi(p)
 
-	factors := factorize(n)
+	// basicPohligHellman finds the digits with bsgs, which does not need the factor
+	// to be prime, so the unproven factors are used as well.
+	factors, probable, rest := factorize(n)
+	if rest.Cmp(Big1) != 0 {
+		panic(fmt.Sprintf("pohlig-hellman: the group order has an unfactored part %d", rest))
+	}
+	factors = append(factors, probable...)
 
 	for i := 0; i < len(factors); i++ {
 		pf := factors[i].fact
@@ -180,3 +180,108 @@ func TestPohligHellman(t *testing.T) {
 		}
 	}
 }
+
+type crtTest struct {
+	a, n []int64
+	x, m int64
+	ok   bool
+}
+
+var crtGeneralizedTests = []crtTest{
+	{[]int64{2, 3, 2}, []int64{3, 5, 7}, 23, 105, true},
+	{[]int64{5, 11}, []int64{12, 18}, 29, 36, true},
+	{[]int64{5, 10}, []int64{12, 18}, 0, 0, false},
+	{[]int64{-1, 7, 3}, []int64{4, 6, 10}, 43, 60, true},
+	{[]int64{3, 3}, []int64{6, 6}, 3, 6, true},
+	{[]int64{1, 0}, []int64{4, 1}, 1, 4, true},
+}
+
+func TestCRTGeneralized(t *testing.T) {
+	for i, r := range crtGeneralizedTests {
+		var a, n []*big.Int
+		for j := range r.a {
+			a = append(a, big.NewInt(r.a[j]))
+			n = append(n, big.NewInt(r.n[j]))
+		}
+
+		x, m, err := crtGeneralized(a, n)
+		if !r.ok {
+			if err == nil {
+				t.Errorf("%s - #%d: expected an error for inconsistent equations", t.Name(), i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s - #%d: %v", t.Name(), i, err)
+		}
+		if x.Int64() != r.x || m.Int64() != r.m {
+			t.Errorf("%s - #%d: got %d mod %d, want %d mod %d", t.Name(), i, x, m, r.x, r.m)
+		}
+	}
+}
+
+func TestCRTCandidates(t *testing.T) {
+	// The moduli share the factor 105, and every residue is known up to the sign.
+	moduli := []*big.Int{big.NewInt(105 * 1009), big.NewInt(105 * 1013), big.NewInt(105 * 1019), big.NewInt(105 * 1021)}
+	c, _ := newCRTCandidates(nil, nil, nil)
+	if c != nil {
+		t.Fatalf("%s: expected an error for no equations", t.Name())
+	}
+
+	// x is not 0 modulo any of the factors, so x != -x modulo all of them.
+	x := big.NewInt(98765432101234)
+	var residues [][]*big.Int
+	for _, n := range moduli {
+		residues = append(residues, []*big.Int{new(big.Int).Mod(x, n), new(big.Int).Neg(x)})
+	}
+
+	// Without a verifier all the consistent combinations are returned.
+	c, err := newCRTCandidates(residues, moduli, nil)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if c.modulus().Int64() != 105*1009*1013*1019*1021 {
+		t.Errorf("%s: wrong modulus %d", t.Name(), c.modulus())
+	}
+	var found bool
+	var count int
+	for s, ok := c.next(); ok; s, ok = c.next() {
+		count++
+		found = found || s.Cmp(x) == 0
+	}
+	if !found {
+		t.Fatalf("%s: %d was not found", t.Name(), x)
+	}
+	// x = -x mod 105 only if 105 divides x, so the signs must agree.
+	if count != 2 {
+		t.Errorf("%s: got %d solutions, want 2", t.Name(), count)
+	}
+
+	// The verifier knows x and prunes the wrong branches early.
+	verify := func(s, m *big.Int) bool {
+		return new(big.Int).Mod(x, m).Cmp(s) == 0
+	}
+	c, _ = newCRTCandidates(residues, moduli, verify)
+	s, ok := c.next()
+	if !ok || s.Cmp(x) != 0 {
+		t.Fatalf("%s: want %d, got %d", t.Name(), x, s)
+	}
+	if _, ok := c.next(); ok {
+		t.Errorf("%s: the verifier accepted a wrong solution", t.Name())
+	}
+	if c.Rejected > 2*len(moduli) {
+		t.Errorf("%s: %d partial solutions were rejected", t.Name(), c.Rejected)
+	}
+
+	// Coprime moduli give 2^4 combinations.
+	coprime := []*big.Int{big.NewInt(1009), big.NewInt(1013), big.NewInt(1019), big.NewInt(1021)}
+	c, _ = newCRTCandidates(residues, coprime, nil)
+	for count = 0; ; count++ {
+		if _, ok := c.next(); !ok {
+			break
+		}
+	}
+	if count != 16 {
+		t.Errorf("%s: got %d solutions, want 16", t.Name(), count)
+	}
+}
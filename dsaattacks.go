@@ -0,0 +1,86 @@
+package dhpals
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// dsaKeyFromNonce recovers a DSA private key from a signature (r, s) of the hash h
+// made with the known nonce k: x = (s*k - h) / r mod q.
+func dsaKeyFromNonce(q, h, r, s, k *big.Int) *big.Int {
+	x := new(big.Int).Mul(s, k)
+	x.Sub(x, h)
+	rInv := new(big.Int).ModInverse(r, q)
+	if rInv == nil {
+		return nil
+	}
+	x.Mul(x, rInv)
+	return x.Mod(x, q)
+}
+
+// dsaNonceFromReuse recovers the nonce shared by two signatures (r, s1) and (r, s2)
+// of the hashes h1 and h2: k = (h1 - h2) / (s1 - s2) mod q.
+func dsaNonceFromReuse(q, h1, s1, h2, s2 *big.Int) (*big.Int, error) {
+	ds := new(big.Int).Sub(s1, s2)
+	ds.Mod(ds, q)
+	dsInv := new(big.Int).ModInverse(ds, q)
+	if dsInv == nil {
+		return nil, errors.New("dsa: signatures cannot be combined")
+	}
+	k := new(big.Int).Sub(h1, h2)
+	k.Mul(k, dsInv)
+	return k.Mod(k, q), nil
+}
+
+// runDSASmallNonceAttack recovers the private key from a single signature of msg
+// if the nonce is less than bound (Cryptopals challenge 43).
+func runDSASmallNonceAttack(group *dhgroup.GroupParams, msg []byte, r, s *big.Int, getPublicKey func() *big.Int, bound int64) (priv *big.Int) {
+	digest := sha256.Sum256(msg)
+	h := dhgroup.DSAHashToInt(digest[:], group.Q)
+	y := getPublicKey()
+
+	for k := int64(1); k < bound; k++ {
+		x := dsaKeyFromNonce(group.Q, h, r, s, big.NewInt(k))
+		if x != nil && group.ScalarBaseExp(x).Cmp(y) == 0 {
+			return x
+		}
+	}
+	return nil
+}
+
+// runDSANonceReuseAttack asks the oracle to sign distinct messages until two signatures
+// share r, i.e. the nonce was reused, and recovers the private key (Cryptopals challenge 44).
+func runDSANonceReuseAttack(group *dhgroup.GroupParams, sign func([]byte) (*big.Int, *big.Int), getPublicKey func() *big.Int, maxQueries int) (priv *big.Int) {
+	type signature struct {
+		h, s *big.Int
+	}
+	seen := make(map[string]signature)
+	y := getPublicKey()
+
+	for i := 0; i < maxQueries; i++ {
+		msg := []byte("message #" + strconv.Itoa(i))
+		digest := sha256.Sum256(msg)
+		h := dhgroup.DSAHashToInt(digest[:], group.Q)
+		r, s := sign(msg)
+
+		prev, ok := seen[r.String()]
+		if !ok {
+			seen[r.String()] = signature{h, s}
+			continue
+		}
+
+		k, err := dsaNonceFromReuse(group.Q, prev.h, prev.s, h, s)
+		if err != nil {
+			continue
+		}
+		x := dsaKeyFromNonce(group.Q, h, r, s, k)
+		if x != nil && group.ScalarBaseExp(x).Cmp(y) == 0 {
+			return x
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestDSAKeyFromNonce(t *testing.T) {
+	group := dhgroup.MODP2048S256().DHParams()
+	key, _ := group.GenerateKey(rand.Reader)
+	k := big.NewInt(0xdeadbeef)
+
+	digest := sha256.Sum256([]byte("known nonce"))
+	r, s, err := group.Sign(key.Private, digest[:], func(*big.Int) (*big.Int, error) {
+		return k, nil
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+
+	x := dsaKeyFromNonce(group.Q, dhgroup.DSAHashToInt(digest[:], group.Q), r, s, k)
+	if x.Cmp(key.Private) != 0 {
+		t.Fatalf("%s: wrong private key was recovered", t.Name())
+	}
+}
+
+func TestDSASmallNonceAttack(t *testing.T) {
+	group := dhgroup.MODP1024S160().DHParams()
+
+	// The nonce is at most 16 bits long.
+	smallNonce := func(*big.Int) (*big.Int, error) {
+		k, err := rand.Int(rand.Reader, big.NewInt(1<<16-1))
+		if err != nil {
+			return nil, err
+		}
+		return k.Add(k, Big1), nil
+	}
+	sign, getPublicKey, isKeyCorrect := newDSAOracle(dhgroup.ModP1024s160, smallNonce)
+
+	msg := []byte("For those that envy a MC it can be hazardous to your health")
+	r, s := sign(msg)
+
+	x := runDSASmallNonceAttack(group, msg, r, s, getPublicKey, 1<<16)
+	if x == nil || !isKeyCorrect(x.Bytes()) {
+		t.Fatalf("%s: wrong private key was found in the small nonce attack", t.Name())
+	}
+}
+
+func TestDSANonceReuseAttack(t *testing.T) {
+	group := dhgroup.MODP2048S224().DHParams()
+
+	// The nonce source repeats a few nonces.
+	pool := make([]*big.Int, 4)
+	for i := range pool {
+		pool[i], _ = dhgroup.RandomNonce(rand.Reader)(group.Q)
+	}
+	reusedNonce := func(*big.Int) (*big.Int, error) {
+		i, err := rand.Int(rand.Reader, big.NewInt(int64(len(pool))))
+		if err != nil {
+			return nil, err
+		}
+		return pool[i.Int64()], nil
+	}
+	sign, getPublicKey, isKeyCorrect := newDSAOracle(dhgroup.ModP2048s224, reusedNonce)
+
+	x := runDSANonceReuseAttack(group, sign, getPublicKey, 100)
+	if x == nil || !isKeyCorrect(x.Bytes()) {
+		t.Fatalf("%s: wrong private key was found in the nonce reuse attack", t.Name())
+	}
+}
@@ -0,0 +1,82 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// runElGamalSmallSubgroupAttack recovers the static ElGamal key from a decryption oracle
+// that does not validate C1. For a small prime factor r of the cofactor and h of order r,
+// the oracle decrypts (h, 1) to h^-x, which reveals x mod r by exhaustive search.
+// The residues are combined with the CRT until their product exceeds q.
+func runElGamalSmallSubgroupAttack(p, q, cofactor *big.Int, decrypt func(*dhgroup.ElGamalCiphertext) *big.Int) (priv *big.Int) {
+	var A, N []*big.Int
+	n := big.NewInt(1)
+	pm1 := new(big.Int).Sub(p, Big1)
+
+	for _, f := range factorizeBound(cofactor, 1<<16) {
+		r := f.fact
+		if !r.IsInt64() || r.Int64() > 1<<16 || divides(r, q) {
+			continue
+		}
+
+		// Find an element h of order r.
+		e := new(big.Int).Div(pm1, r)
+		h := new(big.Int)
+		for h.Cmp(Big1) <= 0 {
+			b, err := rand.Int(rand.Reader, pm1)
+			if err != nil {
+				panic(err)
+			}
+			h.Exp(b, e, p)
+		}
+
+		// m = h^-x, so h^x = m^-1.
+		m := decrypt(&dhgroup.ElGamalCiphertext{C1: h, C2: Big1})
+		target := new(big.Int).ModInverse(m, p)
+
+		hx := big.NewInt(1)
+		for x := int64(0); x < r.Int64(); x++ {
+			if hx.Cmp(target) == 0 {
+				A = append(A, big.NewInt(x))
+				N = append(N, r)
+				n.Mul(n, r)
+				break
+			}
+			hx.Mul(hx, h)
+			hx.Mod(hx, p)
+		}
+
+		if n.Cmp(q) > 0 {
+			break
+		}
+	}
+
+	priv, _, err := crt(A, N)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// runElGamalMalleabilityAttack decrypts the challenge ciphertext with a decryption oracle
+// that refuses to decrypt only the challenge itself. As ElGamal is multiplicatively
+// homomorphic, the challenge times an encryption of 2 is another ciphertext of 2m,
+// and the oracle decrypts it.
+func runElGamalMalleabilityAttack(group dhgroup.DHScheme, y *big.Int, challenge *dhgroup.ElGamalCiphertext, decrypt func(*dhgroup.ElGamalCiphertext) (*big.Int, error)) (m *big.Int) {
+	p := group.DHParams().P
+	two, err := dhgroup.ElGamalEncrypt(group, rand.Reader, y, Big2)
+	if err != nil {
+		panic(err)
+	}
+
+	m2, err := decrypt(dhgroup.ElGamalMul(group, challenge, two))
+	if err != nil {
+		panic(err)
+	}
+	m = new(big.Int).ModInverse(Big2, p)
+	m.Mul(m, m2)
+	return m.Mod(m, p)
+}
@@ -0,0 +1,36 @@
+package dhpals
+
+import (
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestElGamalSmallSubgroupAttack(t *testing.T) {
+	group := dhgroup.MODP512V57().DHParams()
+
+	decrypt, _, isKeyCorrect := newElGamalOracle(dhgroup.ModP512v57)
+
+	priv := runElGamalSmallSubgroupAttack(group.P, group.Q, group.Cofactor(), decrypt)
+	t.Logf("%s: Private key:%d\n", t.Name(), priv)
+
+	if !isKeyCorrect(priv.Bytes()) {
+		t.Fatalf("%s: wrong private key was found in the ElGamal small-subgroup attack", t.Name())
+	}
+}
+
+func TestElGamalMalleabilityAttack(t *testing.T) {
+	group, _ := dhgroup.GroupForGroupID(dhgroup.ModP2048)
+
+	decrypt, getChallenge, isMessageCorrect := newElGamalChallengeOracle(dhgroup.ModP2048)
+	y, challenge := getChallenge()
+	if _, err := decrypt(challenge); err == nil {
+		t.Fatalf("%s: the oracle decrypted the challenge", t.Name())
+	}
+
+	m := runElGamalMalleabilityAttack(group, y, challenge, decrypt)
+
+	if !isMessageCorrect(m) {
+		t.Fatalf("%s: wrong message was found in the ElGamal malleability attack", t.Name())
+	}
+}
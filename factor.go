@@ -1,7 +1,7 @@
 package dhpals
 
 import (
-	"math"
+	"context"
 	"math/big"
 
 	"github.com/ghhenry/intfact"
@@ -12,12 +12,25 @@ type factor struct {
 	exp  int64
 }
 
-// factorize factorizes an input number using a trivial algorithm and returns factors with theirs exponents.
-// The factors must be less than 2^32.
-func factorize(n *big.Int) []factor {
+// factorize factorizes an input number with factorizeWith tuned for factors of up to 56 bits,
+// and the composites of up to 180 bits left after that are factored with the quadratic sieve.
+// It returns the proven prime factors with theirs exponents in ascending order, the probable
+// prime factors above 2^64 that are not proven, and the part of n that was not factored
+// (1 if the factorization is complete).
+func factorize(n *big.Int) (factors, probable []factor, rest *big.Int) {
+	f, err := factorizeWith(context.Background(), n, factorConfig{FactorBits: 56, SieveBits: 180})
+	if err != nil {
+		return nil, nil, new(big.Int).Set(n)
+	}
+	return f.Factors, f.Probable, f.Remainder
+}
+
+// factorizeBound works as factorize, but performs trial division only by primes up to bound.
+// If n has prime factors above bound, the last returned factor is the unfactored part of n.
+func factorizeBound(n *big.Int, bound uint32) []factor {
 	factors := make([]factor, 0)
 	l := intfact.NewFactors(n)
-	l.TrialDivision(math.MaxUint32)
+	l.TrialDivision(bound)
 	for p := l.First; p != nil; p = p.Next {
 		factors = append(factors, factor{
 			p.Fac, int64(p.Exp),
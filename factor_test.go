@@ -1,8 +1,11 @@
 package dhpals
 
 import (
+	"context"
 	"math/big"
+	mrand "math/rand"
 	"testing"
+	"time"
 )
 
 type factorizationTest struct {
@@ -36,11 +39,230 @@ func TestFactorization(t *testing.T) {
 	for i, r := range factorizationTests {
 		n, _ := new(big.Int).SetString(r.n, 10)
 		wantedFactors := r.factors
-		gotFactors := factorize(n)
+		gotFactors, probable, rest := factorize(n)
+		if rest.Cmp(Big1) != 0 {
+			t.Fatalf("%s - #%d: factorize(%d): unfactored part %d", t.Name(), i, n, rest)
+		}
 		for j := 0; j < len(wantedFactors); j++ {
 			if gotFactors[j].fact.Cmp(wantedFactors[j].fact) != 0 || gotFactors[j].exp != wantedFactors[j].exp {
 				t.Fatalf("%s - #%d: factorize(%d)", t.Name(), i, n)
 			}
 		}
+		m := big.NewInt(1)
+		for _, ff := range append(gotFactors, probable...) {
+			m.Mul(m, new(big.Int).Exp(ff.fact, big.NewInt(ff.exp), nil))
+		}
+		if m.Cmp(n) != 0 {
+			t.Errorf("%s - #%d: the factors do not multiply to %d", t.Name(), i, n)
+		}
+	}
+}
+
+type splitTest struct {
+	name   string
+	method factorMethod
+	bits   int
+	n, p   string
+}
+
+var splitTests = []splitTest{
+	// 258505319 * 10082670817639453073.
+	{"rho", factorRho, 48, "2606424036085877643621395287", "258505319"},
+	// p - 1 is 5000-smooth, q is a safe prime.
+	{"p-1", factorPM1, 48, "639926940956217246337571985685472648302550231997210603313", "53488961511085816528118197265482446719"},
+	// p + 1 is 5000-smooth, but p - 1 is not.
+	{"p+1", factorPP1, 48, "545558656144436706314423681551827026448703875787362547", "45601089894644735509234373427762461"},
+	// 243091193404753 * 220159815086227. The effort for 60-bit factors makes a failure unlikely.
+	{"ECM", factorECM, 60, "53518912189080664934406636931", ""},
+	// The product of two 64-bit primes.
+	{"SIQS", factorSIQS, 0, "227489500633583658810285603937199734427", "15013673927843451827"},
+}
+
+func TestFactorMethods(t *testing.T) {
+	for _, r := range splitTests {
+		n, _ := new(big.Int).SetString(r.n, 10)
+		d := r.method.Split(context.Background(), n, r.bits)
+		if d == nil || !divides(d, n) || d.Cmp(Big1) == 0 || d.Cmp(n) == 0 {
+			t.Fatalf("%s: %s: no factor of %d was found", t.Name(), r.name, n)
+		}
+		if r.p == "" {
+			continue
+		}
+		p, _ := new(big.Int).SetString(r.p, 10)
+		if d.Cmp(p) != 0 && new(big.Int).Div(n, d).Cmp(p) != 0 {
+			t.Errorf("%s: %s: got %d, want %d", t.Name(), r.name, d, p)
+		}
+	}
+}
+
+func TestFactorizeWith(t *testing.T) {
+	// The test number has a 211-bit composite part without small factors.
+	for i, r := range factorizationTests {
+		n, _ := new(big.Int).SetString(r.n, 10)
+		f, err := factorizeWith(context.Background(), n, factorConfig{FactorBits: 32})
+		if err != nil {
+			t.Fatalf("%s - #%d: %v", t.Name(), i, err)
+		}
+		m := new(big.Int).Set(f.Remainder)
+		for j, ff := range r.factors {
+			if f.Factors[j].fact.Cmp(ff.fact) != 0 || f.Factors[j].exp != ff.exp {
+				t.Fatalf("%s - #%d: factorizeWith(%d)", t.Name(), i, n)
+			}
+		}
+		for _, ff := range append(f.Factors, f.Probable...) {
+			m.Mul(m, new(big.Int).Exp(ff.fact, big.NewInt(ff.exp), nil))
+		}
+		if m.Cmp(n) != 0 {
+			t.Errorf("%s - #%d: the factors and the remainder do not multiply to %d", t.Name(), i, n)
+		}
+	}
+
+	if testing.Short() {
+		t.Skipf("%s: skipping ECM in short mode", t.Name())
+	}
+	// 954034967^3 * 3538334777 * 243091193404753 * 220159815086227 * 860227136418482486752809570143.
+	n := big.NewInt(954034967)
+	n.Exp(n, Big3, nil)
+	for _, s := range []string{"3538334777", "243091193404753", "220159815086227", "860227136418482486752809570143"} {
+		p, _ := new(big.Int).SetString(s, 10)
+		n.Mul(n, p)
+	}
+	f, err := factorizeWith(context.Background(), n, factorConfig{FactorBits: 56})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	want := []factor{
+		{big.NewInt(954034967), 3},
+		{big.NewInt(3538334777), 1},
+		{big.NewInt(220159815086227), 1},
+		{big.NewInt(243091193404753), 1},
+	}
+	if f.Remainder.Cmp(Big1) != 0 || len(f.Factors) != len(want) || len(f.Probable) != 1 {
+		t.Fatalf("%s: incomplete factorization of %d: remainder %d", t.Name(), n, f.Remainder)
+	}
+	for j, ff := range want {
+		if f.Factors[j].fact.Cmp(ff.fact) != 0 || f.Factors[j].exp != ff.exp {
+			t.Errorf("%s: got %d^%d, want %d^%d", t.Name(), f.Factors[j].fact, f.Factors[j].exp, ff.fact, ff.exp)
+		}
+	}
+}
+
+func TestFactorizeWithSieve(t *testing.T) {
+	// 1000 * 932064066788690753854127 * 1030601263623587226190309. The 80-bit factors are
+	// too large for the effort for 24-bit factors, but the 160-bit composite is sieved.
+	n, _ := new(big.Int).SetString("960586405010564291078903979816633813256927055243000", 10)
+	f, err := factorizeWith(context.Background(), n, factorConfig{FactorBits: 24, SieveBits: 160})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if f.Remainder.Cmp(Big1) != 0 || len(f.Factors) != 2 || len(f.Probable) != 2 {
+		t.Fatalf("%s: incomplete factorization of %d: remainder %d", t.Name(), n, f.Remainder)
+	}
+	for j, s := range []string{"932064066788690753854127", "1030601263623587226190309"} {
+		if f.Probable[j].fact.String() != s || f.Probable[j].exp != 1 {
+			t.Errorf("%s: got %d^%d, want %s", t.Name(), f.Probable[j].fact, f.Probable[j].exp, s)
+		}
+	}
+}
+
+func TestGF2Dependencies(t *testing.T) {
+	rnd := mrand.New(mrand.NewSource(24))
+	const nrows, ncols = 100, 90
+	rows := make([][]uint64, nrows)
+	for i := range rows {
+		rows[i] = []uint64{rnd.Uint64(), rnd.Uint64() & (1<<(ncols-64) - 1)}
+	}
+	// Duplicate and zero rows.
+	rows[10] = append([]uint64(nil), rows[20]...)
+	rows[30] = []uint64{0, 0}
+
+	deps := gf2Dependencies(rows, ncols)
+	if len(deps) < nrows-ncols {
+		t.Fatalf("%s: got %d dependencies, want at least %d", t.Name(), len(deps), nrows-ncols)
+	}
+	for _, dep := range deps {
+		sum := make([]uint64, 2)
+		for _, i := range dep {
+			sum[0] ^= rows[i][0]
+			sum[1] ^= rows[i][1]
+		}
+		if len(dep) == 0 || sum[0] != 0 || sum[1] != 0 {
+			t.Fatalf("%s: %v is not a dependency", t.Name(), dep)
+		}
+	}
+}
+
+func TestFactorizeWithRemainder(t *testing.T) {
+	// The product of two 100-bit primes can not be split with the effort for 20-bit factors.
+	n, _ := new(big.Int).SetString("657481449744722601334874091911514057236171659244671439498129", 10)
+	m := new(big.Int).Mul(n, big.NewInt(1000))
+
+	f, err := factorizeWith(context.Background(), m, factorConfig{FactorBits: 20})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if f.Remainder.Cmp(n) != 0 || len(f.Factors) != 2 {
+		t.Errorf("%s: got remainder %d, want %d", t.Name(), f.Remainder, n)
+	}
+
+	// The deadline stops the search for 100-bit factors.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	f, err = factorizeWith(ctx, n, factorConfig{FactorBits: 100})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if f.Remainder.Cmp(n) != 0 {
+		t.Errorf("%s: got remainder %d, want %d", t.Name(), f.Remainder, n)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("%s: cancellation took %v", t.Name(), time.Since(start))
+	}
+}
+
+// siqsBenchmarks are the products of two primes of 64, 80, 90 and 100 bits.
+var siqsBenchmarks = []string{
+	"227489500633583658810285603937199734427",
+	"960586405010564291078903979816633813256927055243",
+	"1081600734905163083776058127071095860512773730803509887",
+	"1310034635581012512685038316425864763773726183357544290502417",
+}
+
+func BenchmarkSIQS128(b *testing.B) {
+	benchmarkSIQS(b, siqsBenchmarks[0])
+}
+
+func BenchmarkSIQS160(b *testing.B) {
+	benchmarkSIQS(b, siqsBenchmarks[1])
+}
+
+func BenchmarkSIQS180(b *testing.B) {
+	benchmarkSIQS(b, siqsBenchmarks[2])
+}
+
+func BenchmarkSIQS200(b *testing.B) {
+	benchmarkSIQS(b, siqsBenchmarks[3])
+}
+
+func benchmarkSIQS(b *testing.B, s string) {
+	n, _ := new(big.Int).SetString(s, 10)
+	for i := 0; i < b.N; i++ {
+		if siqsSplit(context.Background(), n, 0) == nil {
+			b.Fatalf("%s: no factor of %d was found", b.Name(), n)
+		}
+	}
+}
+
+// BenchmarkFactorizeWithSieve factors factorizationTests including the 211-bit composite part.
+func BenchmarkFactorizeWithSieve(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, r := range factorizationTests {
+			n, _ := new(big.Int).SetString(r.n, 10)
+			f, err := factorizeWith(context.Background(), n, factorConfig{FactorBits: 32, SieveBits: 220})
+			if err != nil || f.Remainder.Cmp(Big1) != 0 {
+				b.Fatalf("%s: incomplete factorization of %d", b.Name(), n)
+			}
+		}
 	}
 }
@@ -0,0 +1,613 @@
+package dhpals
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// A factoring engine for the group orders and cofactors met in the attacks.
+//
+// After trial division and a perfect power check, every composite part is split with the
+// methods of the configured pipeline, by default
+//  1. Pollard's rho with Brent's cycle detection, which finds factors of up to ~30 bits;
+//  2. Pollard's p-1, which finds p with smooth p-1;
+//  3. Williams' p+1, which finds p with smooth p+1;
+//  4. Lenstra's ECM on Montgomery curves, whose running time depends on the size of
+//     the factor rather than on the size of n.
+// The effort of every method is chosen for the factors of the requested size. The
+// composites that are small enough and were not split are factored with the
+// self-initializing quadratic sieve (siqs.go). The whole run is bounded by the context,
+// and whatever was not split is returned as the unfactored remainder.
+
+// factorMethod is a method of splitting composite numbers.
+type factorMethod struct {
+	Name string
+	// Split returns a nontrivial factor of the composite n, which is not a perfect power
+	// and has no small factors, or nil if no factor of up to bits bits was found.
+	Split func(ctx context.Context, n *big.Int, bits int) *big.Int
+}
+
+var (
+	factorRho = factorMethod{"Pollard's rho", rhoBrentSplit}
+	factorPM1 = factorMethod{"Pollard's p-1", pm1Split}
+	factorPP1 = factorMethod{"Williams' p+1", pp1Split}
+	factorECM = factorMethod{"ECM", ecmSplit}
+)
+
+// factorConfig configures factorizeWith. Zero values choose defaults.
+type factorConfig struct {
+	// TrialBound is the bound of the trial division. Zero means 2^16.
+	TrialBound uint32
+	// FactorBits is the size of the prime factors the methods are tuned for, e.g. 48 to
+	// find all factors below 2^48 with high probability. Zero means 48.
+	FactorBits int
+	// Methods is the pipeline of splitting methods. Nil means rho, p-1, p+1 and ECM.
+	Methods []factorMethod
+	// SieveBits is the size of the largest composite that is factored with the quadratic
+	// sieve if the methods fail. The methods look only for factors of up to a third of the
+	// size of such composites, since the sieve is faster for larger factors.
+	// Zero means 2*FactorBits, so that the composites that must have factors of up to
+	// FactorBits bits are factored completely. Negative disables the sieve.
+	SieveBits int
+}
+
+// factorization is the result of factorizeWith.
+type factorization struct {
+	// Factors are the prime factors in ascending order. They are less than 2^64,
+	// where the Baillie-PSW test is proven to be correct.
+	Factors []factor
+	// Probable are the factors above 2^64 that passed the Baillie-PSW and Miller-Rabin tests.
+	// They are not proven to be prime.
+	Probable []factor
+	// Remainder is the part of n that was not factored, or 1 if the factorization is complete.
+	Remainder *big.Int
+}
+
+// factorizeWith factors n > 0 with the methods of cfg until ctx is done.
+func factorizeWith(ctx context.Context, n *big.Int, cfg factorConfig) (*factorization, error) {
+	if n.Sign() <= 0 {
+		return nil, errors.New("factor: n must be positive")
+	}
+	trialBound := cfg.TrialBound
+	if trialBound == 0 {
+		trialBound = 1 << 16
+	}
+	bits := cfg.FactorBits
+	if bits == 0 {
+		bits = 48
+	}
+	methods := cfg.Methods
+	if methods == nil {
+		methods = []factorMethod{factorRho, factorPM1, factorPP1, factorECM}
+	}
+	sieveBits := cfg.SieveBits
+	if sieveBits == 0 {
+		sieveBits = 2 * bits
+	}
+
+	primes := make(map[string]*big.Int)
+	exps := make(map[string]int64)
+	add := func(p *big.Int, e int64) {
+		primes[p.String()] = p
+		exps[p.String()] += e
+	}
+	remainder := big.NewInt(1)
+
+	m := new(big.Int).Set(n)
+	r := new(big.Int)
+	for _, p := range primesBelow(uint64(trialBound)) {
+		if m.Cmp(Big1) == 0 {
+			break
+		}
+		l := new(big.Int).SetUint64(p)
+		for {
+			q, _ := new(big.Int).QuoRem(m, l, r)
+			if r.Sign() != 0 {
+				break
+			}
+			m = q
+			add(l, 1)
+		}
+	}
+
+	// Every entry of the queue is a factor of n taken e times.
+	type part struct {
+		n *big.Int
+		e int64
+	}
+	var queue []part
+	if m.Cmp(Big1) != 0 {
+		queue = append(queue, part{m, 1})
+	}
+	for len(queue) > 0 {
+		c := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		if c.n.ProbablyPrime(20) {
+			add(c.n, c.e)
+			continue
+		}
+		if root, k := perfectPower(c.n); k > 1 {
+			queue = append(queue, part{root, c.e * int64(k)})
+			continue
+		}
+
+		var d *big.Int
+		sieve := c.n.BitLen() <= sieveBits
+		splitBits := bits
+		if sieve && c.n.BitLen()/3 < bits {
+			splitBits = c.n.BitLen() / 3
+		}
+		for _, method := range methods {
+			if ctx.Err() != nil {
+				break
+			}
+			if d = method.Split(ctx, c.n, splitBits); d != nil {
+				break
+			}
+		}
+		if d == nil && sieve {
+			d = siqsSplit(ctx, c.n, bits)
+		}
+		if d == nil {
+			remainder.Mul(remainder, new(big.Int).Exp(c.n, big.NewInt(c.e), nil))
+			continue
+		}
+		queue = append(queue, part{d, c.e}, part{new(big.Int).Div(c.n, d), c.e})
+	}
+
+	f := &factorization{Remainder: remainder}
+	for k, p := range primes {
+		if p.BitLen() <= 64 {
+			f.Factors = append(f.Factors, factor{p, exps[k]})
+		} else {
+			f.Probable = append(f.Probable, factor{p, exps[k]})
+		}
+	}
+	for _, fs := range [][]factor{f.Factors, f.Probable} {
+		sort.Slice(fs, func(i, j int) bool { return fs[i].fact.Cmp(fs[j].fact) < 0 })
+	}
+	return f, nil
+}
+
+// perfectPower returns r and the largest k such that n = r^k.
+func perfectPower(n *big.Int) (*big.Int, int) {
+	for k := n.BitLen(); k >= 2; k-- {
+		r := iroot(n, k)
+		if new(big.Int).Exp(r, big.NewInt(int64(k)), nil).Cmp(n) == 0 {
+			return r, k
+		}
+	}
+	return n, 1
+}
+
+// iroot returns the integer k-th root of n > 0 using Newton's method.
+func iroot(n *big.Int, k int) *big.Int {
+	bk := big.NewInt(int64(k))
+	bk1 := big.NewInt(int64(k - 1))
+	// Start above the root: 2^(ceil(bits/k)).
+	x := new(big.Int).Lsh(Big1, uint((n.BitLen()+k-1)/k))
+	for {
+		// y = ((k-1)*x + n/x^(k-1)) / k.
+		y := new(big.Int).Exp(x, bk1, nil)
+		y.Div(n, y)
+		y.Add(y, new(big.Int).Mul(bk1, x))
+		y.Div(y, bk)
+		if y.Cmp(x) >= 0 {
+			return x
+		}
+		x = y
+	}
+}
+
+// factorCheckEvery is the number of iterations between checks for cancellation.
+const factorCheckEvery = 1 << 10
+
+// rhoBrentSplit is Pollard's rho with Brent's cycle detection for f(x) = x^2 + c.
+// The differences are multiplied together and a gcd is taken every 128 steps.
+// It runs for about 2^(bits/2) steps, but at most 2^18: ECM is faster for larger factors.
+func rhoBrentSplit(ctx context.Context, n *big.Int, bits int) *big.Int {
+	const batch = 128
+	limit := int64(1) << uint(math.Min(float64(bits)/2+2, 18))
+
+	f := func(x, c *big.Int) {
+		x.Mul(x, x)
+		x.Add(x, c)
+		x.Mod(x, n)
+	}
+	var steps int64
+	for attempt := 0; attempt < 4; attempt++ {
+		y, _ := rand.Int(rand.Reader, n)
+		c, _ := rand.Int(rand.Reader, n)
+		if c.Sign() == 0 {
+			c.SetInt64(1)
+		}
+		g, q := big.NewInt(1), big.NewInt(1)
+		x, ys, diff := new(big.Int), new(big.Int), new(big.Int)
+
+		for r := int64(1); g.Cmp(Big1) == 0; r *= 2 {
+			x.Set(y)
+			for i := int64(0); i < r; i++ {
+				f(y, c)
+			}
+			for k := int64(0); k < r && g.Cmp(Big1) == 0; k += batch {
+				ys.Set(y)
+				for i := int64(0); i < batch && i < r-k; i++ {
+					f(y, c)
+					q.Mul(q, diff.Sub(x, y))
+					q.Mod(q, n)
+				}
+				g.GCD(nil, nil, q, n)
+			}
+			steps += 2 * r
+			if steps > limit || ctx.Err() != nil {
+				return nil
+			}
+		}
+		if g.Cmp(n) == 0 {
+			// The batch went past the collision, so go back and take gcds one by one.
+			for g.SetInt64(1); g.Cmp(Big1) == 0; {
+				f(ys, c)
+				g.GCD(nil, nil, diff.Sub(x, ys).Abs(diff), n)
+			}
+		}
+		if g.Cmp(Big1) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+	}
+	return nil
+}
+
+// factorEffort contains the ECM parameters for the factors of a given size.
+// p-1 and p+1 use 4 times larger B1, since their steps are cheaper.
+type factorEffort struct {
+	digits    int
+	ecmB1     uint64
+	ecmCurves int
+}
+
+// ecmEfforts are the optimal B1 and the expected numbers of curves to find a factor
+// of the given number of decimal digits with stage 2 bound B2 = 100*B1 (GMP-ECM).
+var ecmEfforts = []factorEffort{
+	{10, 360, 7},
+	{15, 2000, 25},
+	{20, 11000, 90},
+	{25, 50000, 300},
+	{30, 250000, 700},
+}
+
+func effortFor(bits int) factorEffort {
+	digits := int(math.Ceil(float64(bits) * math.Log10(2)))
+	for _, e := range ecmEfforts {
+		if e.digits >= digits {
+			return e
+		}
+	}
+	return ecmEfforts[len(ecmEfforts)-1]
+}
+
+// primeSieve returns the primality table of the numbers up to bound.
+func primeSieve(bound uint64) []bool {
+	prime := make([]bool, bound+1)
+	for i := uint64(2); i <= bound; i++ {
+		prime[i] = true
+	}
+	for i := uint64(2); i*i <= bound; i++ {
+		if prime[i] {
+			for j := i * i; j <= bound; j += i {
+				prime[j] = false
+			}
+		}
+	}
+	return prime
+}
+
+// primePower returns the largest power of p not exceeding bound.
+func primePower(p, bound uint64) *big.Int {
+	q := p
+	for q <= bound/p {
+		q *= p
+	}
+	return new(big.Int).SetUint64(q)
+}
+
+// pm1Split is Pollard's p-1 method. Stage 1 computes a = 2^E mod n, where E is the product
+// of the prime powers up to B1, and finds p with B1-smooth p-1 in gcd(a-1, n).
+// Stage 2 finds p such that p-1 has one more prime factor q up to B2 = 50*B1
+// by accumulating a^q - 1 for consecutive primes q.
+func pm1Split(ctx context.Context, n *big.Int, bits int) *big.Int {
+	b1 := 4 * effortFor(bits).ecmB1
+	b2 := 50 * b1
+
+	primes := primesBelow(b2)
+	a := big.NewInt(2)
+	i := 0
+	for ; i < len(primes) && primes[i] <= b1; i++ {
+		a.Exp(a, primePower(primes[i], b1), n)
+		if i%factorCheckEvery == 0 && ctx.Err() != nil {
+			return nil
+		}
+	}
+	am1 := new(big.Int).Sub(a, Big1)
+	if d := properFactor(am1, n); d != nil {
+		return d
+	}
+	if am1.Sign() == 0 {
+		// p-1 is smooth for all p | n.
+		return nil
+	}
+
+	// a^(q_(j+1)) = a^(q_j) * a^(q_(j+1) - q_j); the gaps are small and even.
+	gaps := make(map[uint64]*big.Int)
+	b := new(big.Int).Exp(a, new(big.Int).SetUint64(primes[i-1]), n)
+	acc := big.NewInt(1)
+	t := new(big.Int)
+	for j := i; j < len(primes); j++ {
+		gap := primes[j] - primes[j-1]
+		ag, ok := gaps[gap]
+		if !ok {
+			ag = new(big.Int).Exp(a, new(big.Int).SetUint64(gap), n)
+			gaps[gap] = ag
+		}
+		b.Mul(b, ag)
+		b.Mod(b, n)
+		acc.Mul(acc, t.Sub(b, Big1))
+		acc.Mod(acc, n)
+		if j%factorCheckEvery == 0 || j == len(primes)-1 {
+			if d := properFactor(acc, n); d != nil {
+				return d
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// properFactor returns gcd(x, n) if it is a nontrivial factor of n.
+func properFactor(x, n *big.Int) *big.Int {
+	g := new(big.Int).GCD(nil, nil, new(big.Int).Mod(x, n), n)
+	if g.Cmp(Big1) == 0 || g.Cmp(n) == 0 {
+		return nil
+	}
+	return g
+}
+
+// pp1Seeds are the starting values A of Williams' p+1 method. For a prime p, the method
+// works with B1-smooth p+1 if A^2-4 is a quadratic nonresidue mod p and with smooth p-1
+// otherwise; trying several seeds makes the first case likely.
+var pp1Seeds = [][2]int64{{2, 7}, {6, 5}, {3, 1}}
+
+// pp1Split is Williams' p+1 method (stage 1). V_E(A) is computed for the product E of the prime
+// powers up to B1 with Lucas sequences, and gcd(V_E(A) - 2, n) contains p with B1-smooth p+1.
+func pp1Split(ctx context.Context, n *big.Int, bits int) *big.Int {
+	b1 := 4 * effortFor(bits).ecmB1
+	primes := primesBelow(b1)
+
+	for _, seed := range pp1Seeds {
+		inv := new(big.Int).ModInverse(big.NewInt(seed[1]), n)
+		if inv == nil {
+			return properFactor(big.NewInt(seed[1]), n)
+		}
+		v := inv.Mul(inv, big.NewInt(seed[0]))
+		v.Mod(v, n)
+		for i, p := range primes {
+			v = lucasV(v, primePower(p, b1), n)
+			if i%factorCheckEvery == 0 && ctx.Err() != nil {
+				return nil
+			}
+		}
+		if d := properFactor(new(big.Int).Sub(v, Big2), n); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// lucasV returns V_k(a) mod n for V_0 = 2, V_1 = a and V_j = a*V_(j-1) - V_(j-2)
+// using the ladder V_2j = V_j^2 - 2, V_(2j+1) = V_j*V_(j+1) - a.
+func lucasV(a, k, n *big.Int) *big.Int {
+	v0, v1 := big.NewInt(2), new(big.Int).Set(a)
+	t := new(big.Int)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		// t = V_j*V_(j+1) - a = V_(2j+1).
+		t.Mul(v0, v1)
+		t.Sub(t, a)
+		t.Mod(t, n)
+		if k.Bit(i) == 1 {
+			v1.Mul(v1, v1)
+			v1.Sub(v1, Big2)
+			v1.Mod(v1, n)
+			v0.Set(t)
+		} else {
+			v0.Mul(v0, v0)
+			v0.Sub(v0, Big2)
+			v0.Mod(v0, n)
+			v1.Set(t)
+		}
+	}
+	return v0
+}
+
+// ecmPoint is a point (X:Z) of a Montgomery curve in projective x-only coordinates.
+type ecmPoint struct {
+	x, z *big.Int
+}
+
+// ecmCurve is a Montgomery curve B*y^2 = x^3 + A*x^2 + x mod n with a24 = (A+2)/4.
+type ecmCurve struct {
+	n, a24 *big.Int
+}
+
+// double returns 2P.
+func (c *ecmCurve) double(p ecmPoint) ecmPoint {
+	s := new(big.Int).Add(p.x, p.z)
+	s.Mul(s, s)
+	d := new(big.Int).Sub(p.x, p.z)
+	d.Mul(d, d)
+	// t = 4XZ = (X+Z)^2 - (X-Z)^2.
+	t := new(big.Int).Sub(s, d)
+	x := new(big.Int).Mul(s, d)
+	x.Mod(x, c.n)
+	z := new(big.Int).Mul(c.a24, t)
+	z.Add(z, d)
+	z.Mul(z, t)
+	z.Mod(z, c.n)
+	return ecmPoint{x, z}
+}
+
+// add returns P+Q given diff = P-Q.
+func (c *ecmCurve) add(p, q, diff ecmPoint) ecmPoint {
+	u := new(big.Int).Sub(p.x, p.z)
+	u.Mul(u, new(big.Int).Add(q.x, q.z))
+	v := new(big.Int).Add(p.x, p.z)
+	v.Mul(v, new(big.Int).Sub(q.x, q.z))
+	s := new(big.Int).Add(u, v)
+	s.Mul(s, s)
+	s.Mod(s, c.n)
+	d := u.Sub(u, v)
+	d.Mul(d, d)
+	d.Mod(d, c.n)
+	x := s.Mul(s, diff.z)
+	x.Mod(x, c.n)
+	z := d.Mul(d, diff.x)
+	z.Mod(z, c.n)
+	return ecmPoint{x, z}
+}
+
+// mul returns kP for k > 0 using the Montgomery ladder.
+func (c *ecmCurve) mul(p ecmPoint, k *big.Int) ecmPoint {
+	r0, r1 := p, c.double(p)
+	for i := k.BitLen() - 2; i >= 0; i-- {
+		if k.Bit(i) == 1 {
+			r0, r1 = c.add(r1, r0, p), c.double(r1)
+		} else {
+			r0, r1 = c.double(r0), c.add(r1, r0, p)
+		}
+	}
+	return r0
+}
+
+// ecmSplit is Lenstra's elliptic curve method. On a random curve with Suyama's
+// parametrization, the order of the group mod p is divisible by 12 and behaves like
+// a random number otherwise. If it is B1-smooth (or has one more prime factor up to B2),
+// the point Q = E*P is zero mod p after stage 1 (or q*Q after stage 2), which is found
+// as gcd(Z, n).
+func ecmSplit(ctx context.Context, n *big.Int, bits int) *big.Int {
+	effort := effortFor(bits)
+	b1, b2 := effort.ecmB1, 100*effort.ecmB1
+	const D = 32
+
+	primes := primesBelow(b1)
+	isPrime := primeSieve(b2 + 4*D + 1)
+
+	for curve := 0; curve < effort.ecmCurves; curve++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		c, p, d := newECMCurve(n)
+		if d != nil {
+			return d
+		}
+		if c == nil {
+			continue
+		}
+
+		// Stage 1.
+		for _, l := range primes {
+			p = c.mul(p, primePower(l, b1))
+		}
+		if d := properFactor(p.z, n); d != nil {
+			return d
+		}
+		if p.z.Sign() == 0 {
+			continue
+		}
+
+		// Stage 2: R = r*P runs over r = r0, r0 + 4D, ..., and S_d = 2d*P. If q*P = 0 mod p
+		// for q = r ± 2d, then x(R) = x(S_d) mod p, so the product of X_R*Z_S - X_S*Z_R
+		// is divisible by p.
+		s := make([]ecmPoint, D+1)
+		s[1] = c.double(p)
+		s[2] = c.double(s[1])
+		for i := 3; i <= D; i++ {
+			s[i] = c.add(s[i-1], s[1], s[i-2])
+		}
+		step := c.double(s[D])
+
+		r := b1 + 2*D + 1
+		if r%2 == 0 {
+			r++
+		}
+		rr := c.mul(p, new(big.Int).SetUint64(r))
+		prev := c.mul(p, new(big.Int).SetUint64(r-4*D))
+		acc := big.NewInt(1)
+		t, u := new(big.Int), new(big.Int)
+		for block := 0; r-2*D <= b2; block++ {
+			if isPrime[r] {
+				acc.Mul(acc, rr.z)
+				acc.Mod(acc, n)
+			}
+			for i := uint64(1); i <= D; i++ {
+				if !isPrime[r+2*i] && !isPrime[r-2*i] {
+					continue
+				}
+				t.Mul(rr.x, s[i].z)
+				u.Mul(s[i].x, rr.z)
+				acc.Mul(acc, t.Sub(t, u))
+				acc.Mod(acc, n)
+			}
+			rr, prev = c.add(rr, step, prev), rr
+			r += 4 * D
+			if block%64 == 0 && ctx.Err() != nil {
+				return nil
+			}
+		}
+		if d := properFactor(acc, n); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// newECMCurve returns a random curve with Suyama's parametrization and a point on it:
+// u = σ^2 - 5, v = 4σ, P = (u^3 : v^3) and a24 = (v-u)^3*(3u+v) / (16*u^3*v).
+// If the denominator is not invertible, it returns the factor of n found instead.
+func newECMCurve(n *big.Int) (*ecmCurve, ecmPoint, *big.Int) {
+	sigma, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(7)))
+	if err != nil {
+		panic(err)
+	}
+	sigma.Add(sigma, big.NewInt(6))
+
+	u := new(big.Int).Mul(sigma, sigma)
+	u.Sub(u, big.NewInt(5))
+	u.Mod(u, n)
+	v := new(big.Int).Lsh(sigma, 2)
+	v.Mod(v, n)
+
+	u3 := new(big.Int).Exp(u, Big3, n)
+	x := new(big.Int).Set(u3)
+	z := new(big.Int).Exp(v, Big3, n)
+
+	num := new(big.Int).Sub(v, u)
+	num.Exp(num, Big3, n)
+	num.Mul(num, new(big.Int).Add(new(big.Int).Mul(Big3, u), v))
+	num.Mod(num, n)
+	den := new(big.Int).Mul(u3, v)
+	den.Lsh(den, 4)
+	den.Mod(den, n)
+
+	inv := new(big.Int).ModInverse(den, n)
+	if inv == nil {
+		return nil, ecmPoint{}, properFactor(den, n)
+	}
+	a24 := num.Mul(num, inv)
+	a24.Mod(a24, n)
+	return &ecmCurve{n: n, a24: a24}, ecmPoint{x, z}, nil
+}
@@ -0,0 +1,378 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// Index calculus for the subgroup of prime order q of Z_p^*, where p has up to ~80 bits.
+//
+// Let l_1, ..., l_k be the primes up to a smoothness bound (the factor base) and
+// λ_i = log_g(l_i) mod q. Precisely, λ_i = log(l_i) / log(g) mod q for logarithms to any
+// generator of Z_p^*; this is well defined when q^2 does not divide p-1. Then
+//  1. relation collection: for random e write g^e = a/b mod p with |a|, |b| ~ sqrt(p)
+//     (rational reconstruction) and keep it if both a and b are smooth. Then
+//     e = sum(α_i λ_i) - sum(β_i λ_i) mod q, where a = ∏ l_i^α_i and b = ∏ l_i^β_i;
+//  2. linear algebra: the sparse system is solved mod q by structured Gaussian elimination;
+//  3. individual log: for a random s write y*g^s = a/b mod p with smooth a and b, then
+//     log_g(y) = sum(α_i λ_i) - sum(β_i λ_i) - s mod q.
+//
+// The sign of a and b is ignored since log(-1) = (p-1)/2 = 0 mod q for odd q.
+
+// icExtraRelations is the number of relations collected beyond the number of unknowns.
+const icExtraRelations = 20
+
+// icMaxWork limits the number of candidates to this multiple of the expected one.
+const icMaxWork = 16
+
+// factorBase holds the factor base primes and their known logarithms to the base g mod q.
+type factorBase struct {
+	p, g, q *big.Int
+	primes  []uint64
+	logs    map[int]*big.Int
+}
+
+// icRelation is a sparse row of the linear system: sum(row[i] * λ_i) = rhs mod q.
+type icRelation struct {
+	row map[int]*big.Int
+	rhs *big.Int
+}
+
+// indexCalculusBound returns the smoothness bound for the prime p.
+// Since both halves a and b of a relation have about half the bits of p,
+// the bound is chosen for numbers of that size.
+func indexCalculusBound(p *big.Int) uint64 {
+	lnx := float64(p.BitLen()) / 2 * math.Ln2
+	b := math.Exp(1.1 * math.Sqrt(lnx*math.Log(lnx)))
+	return uint64(math.Max(256, math.Min(b, 1<<16)))
+}
+
+// indexCalculus finds x such that g^x = y mod p, where g is an element of prime order q
+// and q^2 does not divide p-1.
+func indexCalculus(p, g, y, q *big.Int) (*big.Int, error) {
+	fb, err := newFactorBase(p, g, q, indexCalculusBound(p))
+	if err != nil {
+		return nil, err
+	}
+	return fb.log(y)
+}
+
+// newFactorBase collects the relations and computes the logarithms of the factor base.
+func newFactorBase(p, g, q *big.Int, bound uint64) (*factorBase, error) {
+	if p.BitLen() > 126 {
+		return nil, errors.New("index calculus: p is too large")
+	}
+	if new(big.Int).Exp(g, q, p).Cmp(Big1) != 0 || g.Cmp(Big1) == 0 {
+		return nil, errors.New("index calculus: g is not of order q")
+	}
+	pm1 := new(big.Int).Sub(p, Big1)
+	if !divides(q, pm1) || divides(q, new(big.Int).Div(pm1, q)) || q.Bit(0) == 0 {
+		return nil, errors.New("index calculus: q must be odd and divide p-1 exactly once")
+	}
+
+	fb := &factorBase{p: p, g: g, q: q, primes: primesBelow(bound)}
+	relations, err := fb.collectRelations()
+	if err != nil {
+		return nil, err
+	}
+	fb.logs = solveSparse(relations, q)
+	if len(fb.logs) == 0 {
+		return nil, errors.New("index calculus: no logarithms were found")
+	}
+	return fb, nil
+}
+
+// collectRelations collects relations until their number exceeds the number of
+// factor base primes that occur in them.
+func (fb *factorBase) collectRelations() ([]icRelation, error) {
+	var relations []icRelation
+	used := make(map[int]bool)
+
+	// The walk h = g^e, e += step visits random-looking elements with one
+	// multiplication per step.
+	e, err := rand.Int(rand.Reader, fb.q)
+	if err != nil {
+		return nil, err
+	}
+	step, err := rand.Int(rand.Reader, fb.q)
+	if err != nil {
+		return nil, err
+	}
+	h := new(big.Int).Exp(fb.g, e, fb.p)
+	gStep := new(big.Int).Exp(fb.g, step, fb.p)
+
+	limit := icMaxWork * float64(len(fb.primes)+icExtraRelations) * fb.expectedTries()
+	for i := 0; len(relations) < len(used)+icExtraRelations; i++ {
+		if float64(i) > limit {
+			return nil, errors.New("index calculus: not enough relations")
+		}
+		h.Mul(h, gStep)
+		h.Mod(h, fb.p)
+		e.Add(e, step)
+		e.Mod(e, fb.q)
+
+		row, ok := fb.factorRational(h)
+		if !ok {
+			continue
+		}
+		for j := range row {
+			used[j] = true
+		}
+		relations = append(relations, icRelation{row: row, rhs: new(big.Int).Set(e)})
+	}
+	return relations, nil
+}
+
+// log returns log_g(y) mod q using the logarithms of the factor base.
+func (fb *factorBase) log(y *big.Int) (*big.Int, error) {
+	if new(big.Int).Exp(y, fb.q, fb.p).Cmp(Big1) != 0 {
+		return nil, errors.New("index calculus: y is not in the subgroup of order q")
+	}
+
+	s := new(big.Int)
+	h := new(big.Int)
+	limit := icMaxWork * fb.expectedTries()
+	for i := 0; float64(i) < limit; i++ {
+		var err error
+		s, err = rand.Int(rand.Reader, fb.q)
+		if err != nil {
+			return nil, err
+		}
+		h.Exp(fb.g, s, fb.p)
+		h.Mul(h, y)
+		h.Mod(h, fb.p)
+
+		row, ok := fb.factorRational(h)
+		if !ok {
+			continue
+		}
+
+		x := new(big.Int).Neg(s)
+		known := true
+		for j, c := range row {
+			l, ok := fb.logs[j]
+			if !ok {
+				known = false
+				break
+			}
+			x.Add(x, new(big.Int).Mul(c, l))
+		}
+		if known {
+			return x.Mod(x, fb.q), nil
+		}
+	}
+	return nil, errors.New("index calculus: individual logarithm was not found")
+}
+
+// expectedTries returns the expected number of elements tried per relation.
+// Both a and b of about sqrt(p) must be smooth, and a number x is smooth
+// over the primes up to the bound with the probability about u^-u, where u = ln(x)/ln(bound).
+func (fb *factorBase) expectedTries() float64 {
+	u := float64(fb.p.BitLen()) / 2 / math.Log2(float64(fb.primes[len(fb.primes)-1]))
+	return math.Pow(u, 2*u)
+}
+
+// factorRational writes h = a/b mod p and returns the factorization of a/b over
+// the factor base as a sparse row (exponents of b are negative) if both are smooth.
+func (fb *factorBase) factorRational(h *big.Int) (map[int]*big.Int, bool) {
+	a, b := rationalReconstruction(h, fb.p)
+	row := make(map[int]*big.Int)
+	if !fb.factorSmooth(a, 1, row) || !fb.factorSmooth(b, -1, row) {
+		return nil, false
+	}
+	for j, c := range row {
+		if c.Sign() == 0 {
+			delete(row, j)
+		}
+	}
+	return row, true
+}
+
+// factorSmooth adds sign*exponents of the factorization of n to row
+// and reports whether n is smooth. It gives up early if the part of n left after
+// the division by the first eighth of the primes is larger than bound^2:
+// such n are rarely smooth and most of the time is spent on them otherwise.
+func (fb *factorBase) factorSmooth(n uint64, sign int64, row map[int]*big.Int) bool {
+	var exps []int
+	bound := fb.primes[len(fb.primes)-1]
+	abort := len(fb.primes) / 8
+	for j, l := range fb.primes {
+		if n == 1 {
+			break
+		}
+		if l*l > n {
+			// n is prime.
+			k := sort.Search(len(fb.primes), func(i int) bool { return fb.primes[i] >= n })
+			if k == len(fb.primes) || fb.primes[k] != n {
+				return false
+			}
+			exps = append(exps, k)
+			n = 1
+			break
+		}
+		if j == abort && n/bound > bound {
+			return false
+		}
+		for n%l == 0 {
+			n /= l
+			exps = append(exps, j)
+		}
+	}
+	if n != 1 {
+		return false
+	}
+
+	for _, j := range exps {
+		if row[j] == nil {
+			row[j] = new(big.Int)
+		}
+		row[j].Add(row[j], big.NewInt(sign))
+	}
+	return true
+}
+
+// rationalReconstruction returns a, b > 0 such that h = ±a/b mod p and a, b < 2*sqrt(p).
+// It runs the extended Euclidean algorithm on (p, h) until the remainder drops below sqrt(p).
+func rationalReconstruction(h, p *big.Int) (uint64, uint64) {
+	bound := new(big.Int).Sqrt(p)
+	r0, r1 := new(big.Int).Set(p), new(big.Int).Set(h)
+	t0, t1 := new(big.Int), big.NewInt(1)
+	q, tmp := new(big.Int), new(big.Int)
+	for r1.Cmp(bound) > 0 {
+		q.Div(r0, r1)
+		tmp.Mul(q, r1)
+		r0.Sub(r0, tmp)
+		r0, r1 = r1, r0
+		tmp.Mul(q, t1)
+		t0.Sub(t0, tmp)
+		t0, t1 = t1, t0
+	}
+	// h*t1 = r1 mod p.
+	return r1.Uint64(), new(big.Int).Abs(t1).Uint64()
+}
+
+// primesBelow returns the primes up to bound using the sieve of Eratosthenes.
+func primesBelow(bound uint64) []uint64 {
+	var primes []uint64
+	for i, prime := range primeSieve(bound) {
+		if prime {
+			primes = append(primes, uint64(i))
+		}
+	}
+	return primes
+}
+
+// solveSparse solves the sparse linear system mod prime q by structured Gaussian
+// elimination and returns the unknowns that are uniquely determined.
+//
+// Each step eliminates the column with the fewest entries (Markowitz pivoting),
+// choosing the sparsest row containing it as the pivot. Large primes occur rarely,
+// so the fill-in stays low until only the dense columns of the small primes are left.
+func solveSparse(relations []icRelation, q *big.Int) map[int]*big.Int {
+	maxCol := -1
+	for _, r := range relations {
+		for j := range r.row {
+			if j > maxCol {
+				maxCol = j
+			}
+		}
+	}
+
+	active := make([]*icRelation, len(relations))
+	count := make([]int, maxCol+1)
+	for i := range relations {
+		active[i] = &relations[i]
+		for j := range relations[i].row {
+			count[j]++
+		}
+	}
+	eliminated := make([]bool, maxCol+1)
+	var order []int
+	pivots := make(map[int]*icRelation)
+
+	tmp := new(big.Int)
+	for {
+		c := -1
+		for j, n := range count {
+			if !eliminated[j] && n > 0 && (c < 0 || n < count[c]) {
+				c = j
+			}
+		}
+		if c < 0 {
+			break
+		}
+		eliminated[c] = true
+
+		pi := -1
+		for i, r := range active {
+			if _, ok := r.row[c]; ok && (pi < 0 || len(r.row) < len(active[pi].row)) {
+				pi = i
+			}
+		}
+		pivot := active[pi]
+		active[pi] = active[len(active)-1]
+		active = active[:len(active)-1]
+		for j := range pivot.row {
+			count[j]--
+		}
+
+		// Normalize the pivot row.
+		inv := new(big.Int).ModInverse(pivot.row[c], q)
+		for _, v := range pivot.row {
+			v.Mul(v, inv).Mod(v, q)
+		}
+		pivot.rhs.Mul(pivot.rhs, inv).Mod(pivot.rhs, q)
+		pivots[c] = pivot
+		order = append(order, c)
+
+		for _, r := range active {
+			f, ok := r.row[c]
+			if !ok {
+				continue
+			}
+			f = new(big.Int).Set(f)
+			for j, v := range pivot.row {
+				w, ok := r.row[j]
+				if !ok {
+					w = new(big.Int)
+					r.row[j] = w
+					count[j]++
+				}
+				w.Sub(w, tmp.Mul(f, v)).Mod(w, q)
+				if w.Sign() == 0 {
+					delete(r.row, j)
+					count[j]--
+				}
+			}
+			r.rhs.Sub(r.rhs, tmp.Mul(f, pivot.rhs)).Mod(r.rhs, q)
+		}
+	}
+
+	// Back substitution: the pivot row of a column only contains the columns
+	// eliminated after it.
+	logs := make(map[int]*big.Int)
+	for i := len(order) - 1; i >= 0; i-- {
+		c := order[i]
+		pivot := pivots[c]
+		x := new(big.Int).Set(pivot.rhs)
+		known := true
+		for j, v := range pivot.row {
+			if j == c {
+				continue
+			}
+			l, ok := logs[j]
+			if !ok {
+				known = false
+				break
+			}
+			x.Sub(x, tmp.Mul(v, l))
+		}
+		if known {
+			logs[c] = x.Mod(x, q)
+		}
+	}
+	return logs
+}
@@ -0,0 +1,64 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// Safe primes p = 2q + 1; 4 generates the subgroup of order q.
+var indexCalculusTests = []string{
+	"226800206373803",
+	"16741499554786516463",
+	"3748281115694198370359",
+	"915189623608292544119963",
+}
+
+func TestRationalReconstruction(t *testing.T) {
+	p, _ := new(big.Int).SetString(indexCalculusTests[1], 10)
+	for i := 0; i < 100; i++ {
+		h, _ := rand.Int(rand.Reader, p)
+		h.Add(h, Big1)
+		a, b := rationalReconstruction(h, p)
+
+		// h*b = ±a mod p.
+		hb := new(big.Int).Mul(h, new(big.Int).SetUint64(b))
+		hb.Mod(hb, p)
+		A := new(big.Int).SetUint64(a)
+		if hb.Cmp(A) != 0 && hb.Add(hb, A).Cmp(p) != 0 {
+			t.Fatalf("%s: %d != ±%d/%d mod p", t.Name(), h, a, b)
+		}
+	}
+}
+
+func TestIndexCalculus(t *testing.T) {
+	for _, s := range indexCalculusTests {
+		p, _ := new(big.Int).SetString(s, 10)
+		if testing.Short() && p.BitLen() > 64 {
+			continue
+		}
+		q := new(big.Int).Rsh(p, 1)
+		g := big.NewInt(4)
+
+		x, _ := rand.Int(rand.Reader, q)
+		y := new(big.Int).Exp(g, x, p)
+
+		xx, err := indexCalculus(p, g, y, q)
+		if err != nil {
+			t.Fatalf("%s: %d-bit p: %v", t.Name(), p.BitLen(), err)
+		}
+		if xx.Cmp(x) != 0 {
+			t.Fatalf("%s: %d-bit p: want %d, got %d", t.Name(), p.BitLen(), x, xx)
+		}
+	}
+}
+
+func BenchmarkIndexCalculus(b *testing.B) {
+	p, _ := new(big.Int).SetString(indexCalculusTests[1], 10)
+	q := new(big.Int).Rsh(p, 1)
+	g := big.NewInt(4)
+	y := new(big.Int).Exp(g, big.NewInt(1234567890123), p)
+	for n := 0; n < b.N; n++ {
+		_, _ = indexCalculus(p, g, y, q)
+	}
+}
@@ -0,0 +1,294 @@
+package dhpals
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Parallel kangaroo algorithm of van Oorschot and Wiener with distinguished points.
+//
+// Every worker runs a tame kangaroo starting from g^(a + w/2 + r) and a wild one
+// starting from y*g^r for small random r, where w = b - a is the width of the interval.
+// All kangaroos use the same jumps g^(2^i), so once a kangaroo lands on a point visited by
+// another one, it follows the same path afterwards. A point is distinguished if its
+// low bits are zero; such points are stored in a table shared by all workers, so
+// a collision of a tame and a wild kangaroo is detected at the next distinguished point.
+//
+// With m kangaroos the mean jump is about m*sqrt(w)/4 and the expected total number of
+// jumps is about 2*sqrt(w) plus m*2^d for d distinguished bits.
+
+// kangarooConfig configures catchKangarooParallel. Zero values choose defaults.
+type kangarooConfig struct {
+	// Workers is the number of goroutines, each running a tame and a wild kangaroo.
+	// Zero means runtime.NumCPU().
+	Workers int
+	// DistinguishedBits is the number of low zero bits of a distinguished point.
+	// Zero chooses it from the width of the interval and the number of kangaroos.
+	DistinguishedBits int
+}
+
+// kangarooStats reports the work done by catchKangarooParallel.
+type kangarooStats struct {
+	Kangaroos           int
+	DistinguishedBits   int
+	ExpectedJumps       float64
+	Jumps               int64
+	DistinguishedPoints int64
+	// Resets is the number of kangaroos restarted after a collision with a kangaroo of the same kind.
+	Resets int64
+}
+
+// kangarooMaxWork limits the number of jumps to this multiple of the expected one.
+const kangarooMaxWork = 16
+
+// kangarooCheckEvery is the number of jumps between checks for cancellation.
+const kangarooCheckEvery = 256
+
+type kangarooTrap struct {
+	tame bool
+	dist *big.Int
+}
+
+type kangarooHunt struct {
+	p, g, y *big.Int
+	a, w    *big.Int
+	jumps   []*big.Int // g^(2^i)
+	sizes   []*big.Int // 2^i
+	mean    *big.Int
+	mask    big.Word
+
+	mu    sync.Mutex
+	traps map[string]kangarooTrap
+
+	result   chan *big.Int
+	stats    kangarooStats
+	maxJumps int64
+}
+
+type kangaroo struct {
+	tame bool
+	x    *big.Int // the current point
+	dist *big.Int // g^dist = x for a tame kangaroo and y*g^dist = x for a wild one
+}
+
+// catchKangarooParallel finds x in [a, b] such that g^x = y mod p.
+// It stops when ctx is cancelled and returns ctx.Err() in that case.
+func catchKangarooParallel(ctx context.Context, p, g, y, a, b *big.Int, cfg kangarooConfig) (*big.Int, kangarooStats, error) {
+	w := new(big.Int).Sub(b, a)
+	if w.Sign() < 0 {
+		return nil, kangarooStats{}, errors.New("kangaroo: empty interval")
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	m := 2 * workers
+	sqrtW := new(big.Int).Sqrt(w)
+	sqrtW.Add(sqrtW, Big1)
+
+	// The jumps are 2^0, ..., 2^(k-1) with the mean (2^k - 1)/k close to m*sqrt(w)/4.
+	target := new(big.Int).Mul(sqrtW, big.NewInt(int64(m)))
+	target.Rsh(target, 2)
+	k := 1
+	for kangarooMeanJump(k).Cmp(target) < 0 {
+		k++
+	}
+
+	d := cfg.DistinguishedBits
+	if d <= 0 {
+		// Keep the overhead m*2^d at about 1/8 of the 2*sqrt(w) jumps.
+		over := new(big.Int).Div(sqrtW, big.NewInt(int64(4*m)))
+		d = over.BitLen() - 1
+		if d < 0 {
+			d = 0
+		}
+		if d > 24 {
+			d = 24
+		}
+	}
+	if d >= 32 {
+		return nil, kangarooStats{}, errors.New("kangaroo: too many distinguished bits")
+	}
+
+	expected, _ := new(big.Float).SetInt(sqrtW).Float64()
+	expected = 2*expected + float64(m)*math.Pow(2, float64(d))
+
+	h := &kangarooHunt{
+		p: p, g: g, y: y, a: a, w: w,
+		mean:     kangarooMeanJump(k),
+		mask:     big.Word(1)<<uint(d) - 1,
+		traps:    make(map[string]kangarooTrap),
+		result:   make(chan *big.Int, 1),
+		maxJumps: int64(kangarooMaxWork * expected),
+		stats: kangarooStats{
+			Kangaroos:         m,
+			DistinguishedBits: d,
+			ExpectedJumps:     expected,
+		},
+	}
+	h.jumps = make([]*big.Int, k)
+	h.sizes = make([]*big.Int, k)
+	h.jumps[0] = new(big.Int).Set(g)
+	h.sizes[0] = big.NewInt(1)
+	for i := 1; i < k; i++ {
+		h.jumps[i] = new(big.Int).Mul(h.jumps[i-1], h.jumps[i-1])
+		h.jumps[i].Mod(h.jumps[i], p)
+		h.sizes[i] = new(big.Int).Lsh(Big1, uint(i))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.run(ctx)
+		}()
+	}
+
+	// Wait for a solution, cancellation or all workers giving up.
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var x *big.Int
+	var err error
+	select {
+	case x = <-h.result:
+	case <-done:
+		select {
+		case x = <-h.result:
+		default:
+			err = ctx.Err()
+			if err == nil {
+				err = errors.New("kangaroo: the logarithm was not found")
+			}
+		}
+	}
+	cancel()
+	<-done
+
+	stats := h.stats
+	stats.Jumps = atomic.LoadInt64(&h.stats.Jumps)
+	stats.DistinguishedPoints = atomic.LoadInt64(&h.stats.DistinguishedPoints)
+	stats.Resets = atomic.LoadInt64(&h.stats.Resets)
+	return x, stats, err
+}
+
+// kangarooMeanJump returns (2^k - 1)/k, the mean of the jumps 2^0, ..., 2^(k-1).
+func kangarooMeanJump(k int) *big.Int {
+	n := new(big.Int).Lsh(Big1, uint(k))
+	n.Sub(n, Big1)
+	return n.Div(n, big.NewInt(int64(k)))
+}
+
+// place puts the kangaroo to a new random starting point.
+func (h *kangarooHunt) place(k *kangaroo) {
+	r, err := rand.Int(rand.Reader, h.mean)
+	if err != nil {
+		panic(err)
+	}
+	k.dist = r
+	if k.tame {
+		k.dist.Add(k.dist, h.a)
+		k.dist.Add(k.dist, new(big.Int).Rsh(h.w, 1))
+		k.x = new(big.Int).Exp(h.g, k.dist, h.p)
+	} else {
+		k.x = new(big.Int).Exp(h.g, k.dist, h.p)
+		k.x.Mul(k.x, h.y)
+		k.x.Mod(k.x, h.p)
+	}
+}
+
+func (h *kangarooHunt) run(ctx context.Context) {
+	herd := []*kangaroo{{tame: true}, {tame: false}}
+	for _, k := range herd {
+		h.place(k)
+	}
+
+	var jumps int64
+	defer func() {
+		atomic.AddInt64(&h.stats.Jumps, jumps)
+	}()
+	for {
+		for _, k := range herd {
+			i := 0
+			if words := k.x.Bits(); len(words) > 0 {
+				i = int(uint(words[0]) % uint(len(h.jumps)))
+			}
+			k.x.Mul(k.x, h.jumps[i])
+			k.x.Mod(k.x, h.p)
+			k.dist.Add(k.dist, h.sizes[i])
+			jumps++
+
+			if words := k.x.Bits(); len(words) > 0 && words[0]&h.mask == 0 {
+				if h.trap(k) {
+					return
+				}
+			}
+		}
+
+		if jumps >= kangarooCheckEvery {
+			total := atomic.AddInt64(&h.stats.Jumps, jumps)
+			jumps = 0
+			if total > h.maxJumps {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}
+}
+
+// trap stores a distinguished point and reports whether the logarithm was found.
+func (h *kangarooHunt) trap(k *kangaroo) bool {
+	atomic.AddInt64(&h.stats.DistinguishedPoints, 1)
+	key := string(k.x.Bytes())
+
+	h.mu.Lock()
+	other, ok := h.traps[key]
+	if !ok {
+		h.traps[key] = kangarooTrap{tame: k.tame, dist: new(big.Int).Set(k.dist)}
+	}
+	h.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	if other.tame == k.tame {
+		// Both kangaroos would follow the same path from now on.
+		atomic.AddInt64(&h.stats.Resets, 1)
+		h.place(k)
+		return false
+	}
+
+	// g^tame = y*g^wild, so x = tame - wild.
+	x := new(big.Int)
+	if k.tame {
+		x.Sub(k.dist, other.dist)
+	} else {
+		x.Sub(other.dist, k.dist)
+	}
+	if new(big.Int).Exp(h.g, x, h.p).Cmp(h.y) != 0 {
+		return false
+	}
+	select {
+	case h.result <- x:
+	default:
+	}
+	return true
+}
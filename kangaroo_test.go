@@ -0,0 +1,64 @@
+package dhpals
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestParallelKangaroo(t *testing.T) {
+	group := dhgroup.MODP512V57().DHParams()
+
+	for _, tt := range []struct {
+		bits int
+		cfg  kangarooConfig
+	}{
+		{16, kangarooConfig{}},
+		{28, kangarooConfig{}},
+		{32, kangarooConfig{Workers: 4}},
+		{32, kangarooConfig{Workers: 2, DistinguishedBits: 4}},
+	} {
+		a := big.NewInt(1000)
+		w := new(big.Int).Lsh(Big1, uint(tt.bits))
+		b := new(big.Int).Add(a, w)
+		x, _ := rand.Int(rand.Reader, w)
+		x.Add(x, a)
+		y := new(big.Int).Exp(group.G, x, group.P)
+
+		xx, stats, err := catchKangarooParallel(context.Background(), group.P, group.G, y, a, b, tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: %d bits: %v", t.Name(), tt.bits, err)
+		}
+		t.Logf("%s: %d bits: %d kangaroos, %d distinguished bits: %d jumps (%.0f expected), %d distinguished points, %d resets",
+			t.Name(), tt.bits, stats.Kangaroos, stats.DistinguishedBits, stats.Jumps, stats.ExpectedJumps,
+			stats.DistinguishedPoints, stats.Resets)
+		if xx.Cmp(x) != 0 {
+			t.Fatalf("%s: %d bits: want %d, got %d", t.Name(), tt.bits, x, xx)
+		}
+	}
+}
+
+func TestParallelKangarooCancel(t *testing.T) {
+	group := dhgroup.MODP512V57().DHParams()
+	b := new(big.Int).Lsh(Big1, 100)
+	y := new(big.Int).Exp(group.G, new(big.Int).Rsh(b, 1), group.P)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, stats, err := catchKangarooParallel(ctx, group.P, group.G, y, Big1, b, kangarooConfig{})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("%s: got %v, want %v", t.Name(), err, context.DeadlineExceeded)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("%s: cancellation took %v", t.Name(), time.Since(start))
+	}
+	if stats.Jumps == 0 {
+		t.Errorf("%s: no jumps were reported", t.Name())
+	}
+}
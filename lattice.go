@@ -0,0 +1,196 @@
+package dhpals
+
+import (
+	"errors"
+	"math/big"
+)
+
+// lll reduces the lattice basis b in place with δ = 3/4 using the integral
+// LLL algorithm (H. Cohen, A Course in Computational Algebraic Number Theory, Algorithm 2.6.7).
+// All computations are done with integers, so there are no precision issues.
+// The basis vectors must be linearly independent.
+func lll(b [][]*big.Int) error {
+	n := len(b)
+	if n == 0 {
+		return nil
+	}
+
+	// d[i+1] is the Gram determinant of b[0..i], d[0] = 1.
+	// lambda[k][j] = d[j+1]*mu[k][j].
+	d := make([]*big.Int, n+1)
+	lambda := make([][]*big.Int, n)
+	for i := range lambda {
+		lambda[i] = make([]*big.Int, n)
+		for j := range lambda[i] {
+			lambda[i][j] = new(big.Int)
+		}
+	}
+	d[0] = big.NewInt(1)
+	d[1] = dot(b[0], b[0])
+	if d[1].Sign() == 0 {
+		return errors.New("lll: linearly dependent vectors")
+	}
+
+	t := new(big.Int)
+
+	red := func(k, l int) {
+		t.Lsh(lambda[k][l], 1)
+		if t.CmpAbs(d[l+1]) <= 0 {
+			return
+		}
+		q := roundDiv(lambda[k][l], d[l+1])
+		for i := range b[k] {
+			b[k][i].Sub(b[k][i], t.Mul(q, b[l][i]))
+		}
+		lambda[k][l].Sub(lambda[k][l], t.Mul(q, d[l+1]))
+		for i := 0; i < l; i++ {
+			lambda[k][i].Sub(lambda[k][i], t.Mul(q, lambda[l][i]))
+		}
+	}
+
+	swap := func(k, kmax int) {
+		b[k], b[k-1] = b[k-1], b[k]
+		for j := 0; j < k-1; j++ {
+			lambda[k][j], lambda[k-1][j] = lambda[k-1][j], lambda[k][j]
+		}
+		l := new(big.Int).Set(lambda[k][k-1])
+		bb := new(big.Int).Mul(d[k-1], d[k+1])
+		bb.Add(bb, new(big.Int).Mul(l, l))
+		bb.Quo(bb, d[k])
+		for i := k + 1; i <= kmax; i++ {
+			ti := new(big.Int).Set(lambda[i][k])
+			lambda[i][k].Mul(d[k+1], lambda[i][k-1])
+			lambda[i][k].Sub(lambda[i][k], new(big.Int).Mul(l, ti))
+			lambda[i][k].Quo(lambda[i][k], d[k])
+			lambda[i][k-1].Mul(bb, ti)
+			lambda[i][k-1].Add(lambda[i][k-1], new(big.Int).Mul(l, lambda[i][k]))
+			lambda[i][k-1].Quo(lambda[i][k-1], d[k+1])
+		}
+		d[k] = bb
+	}
+
+	k, kmax := 1, 0
+	for k < n {
+		if k > kmax {
+			// Incremental Gram-Schmidt.
+			kmax = k
+			for j := 0; j <= k; j++ {
+				u := dot(b[k], b[j])
+				for i := 0; i < j; i++ {
+					u.Mul(u, d[i+1])
+					u.Sub(u, new(big.Int).Mul(lambda[k][i], lambda[j][i]))
+					u.Quo(u, d[i])
+				}
+				if j < k {
+					lambda[k][j] = u
+				} else {
+					if u.Sign() == 0 {
+						return errors.New("lll: linearly dependent vectors")
+					}
+					d[k+1] = u
+				}
+			}
+		}
+
+		// Test the Lovász condition 4*d[k+1]*d[k-1] < 3*d[k]^2 - 4*lambda[k][k-1]^2.
+		red(k, k-1)
+		lhs := new(big.Int).Mul(d[k+1], d[k-1])
+		lhs.Lsh(lhs, 2)
+		rhs := new(big.Int).Mul(d[k], d[k])
+		rhs.Mul(rhs, Big3)
+		rhs.Sub(rhs, new(big.Int).Lsh(new(big.Int).Mul(lambda[k][k-1], lambda[k][k-1]), 2))
+		if lhs.Cmp(rhs) < 0 {
+			swap(k, kmax)
+			if k > 1 {
+				k--
+			}
+			continue
+		}
+		for l := k - 2; l >= 0; l-- {
+			red(k, l)
+		}
+		k++
+	}
+	return nil
+}
+
+// dot returns the inner product of x and y.
+func dot(x, y []*big.Int) *big.Int {
+	s := new(big.Int)
+	t := new(big.Int)
+	for i := range x {
+		s.Add(s, t.Mul(x[i], y[i]))
+	}
+	return s
+}
+
+// roundDiv returns x/y rounded to the nearest integer, y > 0.
+func roundDiv(x, y *big.Int) *big.Int {
+	q := new(big.Int).Lsh(x, 1)
+	q.Add(q, y)
+	q.Div(q, new(big.Int).Lsh(y, 1))
+	return q
+}
+
+// solveHNP solves the hidden number problem: it finds s in [0, p) such that
+// 0 <= s*t[i] mod p < bound for all i, using the Boneh-Venkatesan lattice.
+func solveHNP(p, bound *big.Int, t []*big.Int) (*big.Int, error) {
+	m := len(t)
+	dim := m + 2
+
+	// The lattice is spanned by the rows (scaled by p to keep the entries integral):
+	//   p^2 * e_i                           for i < m,
+	//   (p*t_1, ..., p*t_m, bound, 0),
+	//   (-p*bound/2, ..., -p*bound/2, 0, p*bound).
+	// It contains the short vector (p*(s*t_i mod p - bound/2), s*bound, p*bound).
+	p2 := new(big.Int).Mul(p, p)
+	half := new(big.Int).Mul(p, bound)
+	half.Rsh(half, 1)
+	basis := make([][]*big.Int, dim)
+	for i := range basis {
+		basis[i] = make([]*big.Int, dim)
+		for j := range basis[i] {
+			basis[i][j] = new(big.Int)
+		}
+	}
+	for i := 0; i < m; i++ {
+		basis[i][i].Set(p2)
+		basis[m][i].Mul(p, t[i])
+		basis[m+1][i].Neg(half)
+	}
+	basis[m][m].Set(bound)
+	basis[m+1][m+1].Mul(p, bound)
+
+	if err := lll(basis); err != nil {
+		return nil, err
+	}
+
+	pb := new(big.Int).Mul(p, bound)
+	for _, v := range basis {
+		if v[m+1].CmpAbs(pb) != 0 {
+			continue
+		}
+		s := new(big.Int).Quo(v[m], bound)
+		if v[m+1].Sign() < 0 {
+			s.Neg(s)
+		}
+		s.Mod(s, p)
+		if checkHNP(s, p, bound, t) {
+			return s, nil
+		}
+	}
+	return nil, errors.New("hnp: a solution was not found")
+}
+
+// checkHNP reports whether s*t[i] mod p < bound for all i.
+func checkHNP(s, p, bound *big.Int, t []*big.Int) bool {
+	u := new(big.Int)
+	for _, ti := range t {
+		u.Mul(s, ti)
+		u.Mod(u, p)
+		if u.Cmp(bound) >= 0 {
+			return false
+		}
+	}
+	return true
+}
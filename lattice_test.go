@@ -0,0 +1,125 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// checkLLL verifies that the basis is size-reduced and satisfies the Lovász condition with δ = 3/4.
+func checkLLL(b [][]*big.Int) bool {
+	n := len(b)
+	bs := make([][]*big.Rat, n)
+	bn := make([]*big.Rat, n)
+	mu := make([][]*big.Rat, n)
+	for i := 0; i < n; i++ {
+		bs[i] = make([]*big.Rat, len(b[i]))
+		for j := range b[i] {
+			bs[i][j] = new(big.Rat).SetInt(b[i][j])
+		}
+		mu[i] = make([]*big.Rat, n)
+		for j := 0; j < i; j++ {
+			mu[i][j] = new(big.Rat).Quo(ratDot(b[i], bs[j]), bn[j])
+			for l := range bs[i] {
+				bs[i][l].Sub(bs[i][l], new(big.Rat).Mul(mu[i][j], bs[j][l]))
+			}
+			if new(big.Rat).Abs(mu[i][j]).Cmp(big.NewRat(1, 2)) > 0 {
+				return false
+			}
+		}
+		bn[i] = new(big.Rat)
+		for _, x := range bs[i] {
+			bn[i].Add(bn[i], new(big.Rat).Mul(x, x))
+		}
+		if i > 0 {
+			m2 := new(big.Rat).Mul(mu[i][i-1], mu[i][i-1])
+			rhs := new(big.Rat).Sub(big.NewRat(3, 4), m2)
+			rhs.Mul(rhs, bn[i-1])
+			if bn[i].Cmp(rhs) < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func ratDot(x []*big.Int, y []*big.Rat) *big.Rat {
+	s := new(big.Rat)
+	for i := range x {
+		s.Add(s, new(big.Rat).Mul(new(big.Rat).SetInt(x[i]), y[i]))
+	}
+	return s
+}
+
+func TestLLL(t *testing.T) {
+	var lllTests = [][][]int64{
+		{{1, 1, 1}, {-1, 0, 2}, {3, 5, 6}},
+		{{1, 0, 0, 0, 1000}, {0, 1, 0, 0, 2000}, {0, 0, 1, 0, 3001}, {0, 0, 0, 1, 4003}},
+		{{201, 37}, {1648, 297}},
+	}
+	for i, v := range lllTests {
+		b := make([][]*big.Int, len(v))
+		for j := range v {
+			b[j] = make([]*big.Int, len(v[j]))
+			for l := range v[j] {
+				b[j][l] = big.NewInt(v[j][l])
+			}
+		}
+		if err := lll(b); err != nil {
+			t.Fatalf("%s - #%d: %v", t.Name(), i, err)
+		}
+		if !checkLLL(b) {
+			t.Errorf("%s - #%d: the basis is not LLL-reduced: %v", t.Name(), i, b)
+		}
+	}
+
+	// {{201, 37}, {1648, 297}} reduces to {{1, 32}, {40, 1}} up to signs.
+	b := [][]*big.Int{{big.NewInt(201), big.NewInt(37)}, {big.NewInt(1648), big.NewInt(297)}}
+	_ = lll(b)
+	if new(big.Int).Abs(b[0][0]).Cmp(Big1) != 0 || new(big.Int).Abs(b[0][1]).Cmp(big.NewInt(32)) != 0 {
+		t.Errorf("%s: got %v, want the shortest vector (1, 32)", t.Name(), b[0])
+	}
+}
+
+func TestLLLRandom(t *testing.T) {
+	max := new(big.Int).Lsh(Big1, 64)
+	for i := 0; i < 5; i++ {
+		b := make([][]*big.Int, 8)
+		for j := range b {
+			b[j] = make([]*big.Int, 8)
+			for l := range b[j] {
+				b[j][l], _ = rand.Int(rand.Reader, max)
+			}
+		}
+		if err := lll(b); err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if !checkLLL(b) {
+			t.Fatalf("%s: the basis is not LLL-reduced", t.Name())
+		}
+	}
+}
+
+func TestHNP(t *testing.T) {
+	p, _ := new(big.Int).SetString("233970423115425145524320034830162017933", 10)
+	s, _ := rand.Int(rand.Reader, p)
+	// The 16 most significant bits of s*t mod p are zero.
+	bound := new(big.Int).Lsh(Big1, uint(p.BitLen()-16))
+
+	var samples []*big.Int
+	for len(samples) < 14 {
+		ti, _ := rand.Int(rand.Reader, p)
+		u := new(big.Int).Mul(s, ti)
+		if u.Mod(u, p).Cmp(bound) < 0 {
+			samples = append(samples, ti)
+		}
+	}
+
+	s1, err := solveHNP(p, bound, samples)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if s1.Cmp(s) != 0 {
+		t.Fatalf("%s: got %d, want %d", t.Name(), s1, s)
+	}
+}
@@ -0,0 +1,155 @@
+package dhpals
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The logarithms of a factor base only depend on p and g, so they are computed once
+// per prime and stored in a database. Then the individual logarithm of any element is
+// found in a fraction of the precomputation time. This is the idea of the Logjam attack:
+// a handful of 512-bit primes were used by most of the export-grade TLS servers.
+//
+// The database is a text file:
+//
+//	dhpals factor base
+//	p <p>
+//	g <g>
+//	q <q>
+//	bound <smoothness bound>
+//	<prime> <log>
+//	...
+
+const factorBaseHeader = "dhpals factor base"
+
+// writeTo writes the known logarithms of the factor base to w.
+func (fb *factorBase) writeTo(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, factorBaseHeader)
+	fmt.Fprintf(bw, "p %d\ng %d\nq %d\nbound %d\n", fb.p, fb.g, fb.q, fb.primes[len(fb.primes)-1])
+
+	idx := make([]int, 0, len(fb.logs))
+	for j := range fb.logs {
+		idx = append(idx, j)
+	}
+	sort.Ints(idx)
+	for _, j := range idx {
+		fmt.Fprintf(bw, "%d %d\n", fb.primes[j], fb.logs[j])
+	}
+	return bw.Flush()
+}
+
+// readFactorBase reads a factor base written by writeTo and checks every logarithm:
+// for m = (p-1)/q, log_g(l) = λ implies l^m = (g^m)^λ.
+func readFactorBase(r io.Reader) (*factorBase, error) {
+	s := bufio.NewScanner(r)
+	if !s.Scan() || s.Text() != factorBaseHeader {
+		return nil, errors.New("factor base: invalid header")
+	}
+
+	var params [4]*big.Int
+	for i, name := range []string{"p", "g", "q", "bound"} {
+		if !s.Scan() {
+			return nil, errors.New("factor base: unexpected end of file")
+		}
+		f := strings.Fields(s.Text())
+		if len(f) != 2 || f[0] != name {
+			return nil, fmt.Errorf("factor base: %s expected", name)
+		}
+		v, ok := new(big.Int).SetString(f[1], 10)
+		if !ok || v.Sign() <= 0 {
+			return nil, fmt.Errorf("factor base: invalid %s", name)
+		}
+		params[i] = v
+	}
+	if !params[3].IsUint64() || params[3].Uint64() < 2 || params[3].Uint64() > 1<<24 {
+		return nil, errors.New("factor base: invalid bound")
+	}
+
+	fb := &factorBase{
+		p:      params[0],
+		g:      params[1],
+		q:      params[2],
+		primes: primesBelow(params[3].Uint64()),
+		logs:   make(map[int]*big.Int),
+	}
+	pm1 := new(big.Int).Sub(fb.p, Big1)
+	if !divides(fb.q, pm1) {
+		return nil, errors.New("factor base: q does not divide p-1")
+	}
+	m := pm1.Div(pm1, fb.q)
+	gm := new(big.Int).Exp(fb.g, m, fb.p)
+
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) != 2 {
+			return nil, fmt.Errorf("factor base: invalid line %q", s.Text())
+		}
+		l, err := strconv.ParseUint(f[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("factor base: invalid prime %q", f[0])
+		}
+		j := sort.Search(len(fb.primes), func(i int) bool { return fb.primes[i] >= l })
+		if j == len(fb.primes) || fb.primes[j] != l {
+			return nil, fmt.Errorf("factor base: %d is not in the factor base", l)
+		}
+		lambda, ok := new(big.Int).SetString(f[1], 10)
+		if !ok || lambda.Sign() < 0 || lambda.Cmp(fb.q) >= 0 {
+			return nil, fmt.Errorf("factor base: invalid logarithm of %d", l)
+		}
+
+		lm := new(big.Int).Exp(new(big.Int).SetUint64(l), m, fb.p)
+		if lm.Cmp(new(big.Int).Exp(gm, lambda, fb.p)) != 0 {
+			return nil, fmt.Errorf("factor base: wrong logarithm of %d", l)
+		}
+		fb.logs[j] = lambda
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(fb.logs) == 0 {
+		return nil, errors.New("factor base: no logarithms")
+	}
+	return fb, nil
+}
+
+// loadFactorBase reads the factor base database for p and g from path.
+// If the file does not exist, it runs the precomputation and stores the result.
+func loadFactorBase(path string, p, g, q *big.Int) (*factorBase, error) {
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+		fb, err := readFactorBase(f)
+		if err != nil {
+			return nil, err
+		}
+		if fb.p.Cmp(p) != 0 || fb.g.Cmp(g) != 0 || fb.q.Cmp(q) != 0 {
+			return nil, fmt.Errorf("factor base: %s belongs to another group", path)
+		}
+		return fb, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fb, err := newFactorBase(p, g, q, indexCalculusBound(p))
+	if err != nil {
+		return nil, err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := fb.writeTo(out); err != nil {
+		out.Close()
+		return nil, err
+	}
+	return fb, out.Close()
+}
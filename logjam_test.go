@@ -0,0 +1,102 @@
+package dhpals
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// logjamGroup is a toy 64-bit group standing in for an export-grade 512-bit prime.
+const logjamGroup dhgroup.ID = 0x4c4a
+
+func registerLogjamGroup(t *testing.T) dhgroup.DHScheme {
+	p, _ := new(big.Int).SetString(indexCalculusTests[1], 10)
+	err := dhgroup.RegisterGroup(logjamGroup, &dhgroup.GroupParams{
+		P:    p,
+		G:    big.NewInt(4),
+		Q:    new(big.Int).Rsh(p, 1),
+		Name: "toy-export-64",
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	group, _ := dhgroup.GroupForGroupID(logjamGroup)
+	return group
+}
+
+func TestFactorBaseDatabase(t *testing.T) {
+	p, _ := new(big.Int).SetString(indexCalculusTests[0], 10)
+	q := new(big.Int).Rsh(p, 1)
+	g := big.NewInt(4)
+
+	fb, err := newFactorBase(p, g, q, indexCalculusBound(p))
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	var buf bytes.Buffer
+	if err := fb.writeTo(&buf); err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	db := buf.String()
+
+	loaded, err := readFactorBase(strings.NewReader(db))
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if len(loaded.logs) != len(fb.logs) {
+		t.Fatalf("%s: %d logarithms were read, want %d", t.Name(), len(loaded.logs), len(fb.logs))
+	}
+
+	y := new(big.Int).Exp(g, big.NewInt(31337), p)
+	if x, err := loaded.log(y); err != nil || x.Int64() != 31337 {
+		t.Errorf("%s: wrong logarithm from the loaded factor base", t.Name())
+	}
+
+	// A wrong logarithm is detected.
+	lines := strings.Split(db, "\n")
+	f := strings.Fields(lines[5])
+	lines[5] = f[0] + " 1" + f[1]
+	if _, err := readFactorBase(strings.NewReader(strings.Join(lines, "\n"))); err == nil {
+		t.Errorf("%s: corrupted factor base was accepted", t.Name())
+	}
+}
+
+func TestLogjamAttack(t *testing.T) {
+	group := registerLogjamGroup(t)
+	defer dhgroup.UnregisterGroup(logjamGroup)
+
+	var getPublicKeys []func() *big.Int
+	var isKeyCorrect []func([]byte) bool
+	for i := 0; i < 20; i++ {
+		_, correct, getPublicKey := newDHOracle(logjamGroup)
+		getPublicKeys = append(getPublicKeys, getPublicKey)
+		isKeyCorrect = append(isKeyCorrect, correct)
+	}
+
+	dir, err := ioutil.TempDir("", "logjam")
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "toy-export-64.db")
+
+	// The first run does the precomputation, the second one only loads the database.
+	for _, run := range []string{"precomputation", "database"} {
+		start := time.Now()
+		privs := runLogjamAttack(group, path, getPublicKeys)
+		t.Logf("%s: %s: %d keys in %v", t.Name(), run, len(privs), time.Since(start))
+
+		for i, priv := range privs {
+			if !isKeyCorrect[i](priv.Bytes()) {
+				t.Fatalf("%s: %s: wrong private key #%d", t.Name(), run, i)
+			}
+		}
+	}
+}
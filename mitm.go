@@ -0,0 +1,203 @@
+package dhpals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// The messages of the protocol simulated by runMITMExchange:
+//
+//	A -> B: p, g           (mitmGroupMessage)
+//	B -> A: p, g           (mitmGroupMessage, the group B has accepted)
+//	A -> B: A = g^a mod p  (mitmKeyMessage)
+//	B -> A: B = g^b mod p  (mitmKeyMessage)
+//	A -> B: AES-CBC(k, msg), iv  (mitmDataMessage)
+//	B -> A: AES-CBC(k, msg), iv' (mitmDataMessage, B echoes the message)
+//
+// where k = SHA-256(s)[0:16] and s is the shared secret.
+
+// mitmGroupMessage is used to negotiate the group.
+type mitmGroupMessage struct {
+	P, G *big.Int
+}
+
+// mitmKeyMessage carries a public key.
+type mitmKeyMessage struct {
+	Y *big.Int
+}
+
+// mitmDataMessage carries an AES-CBC encrypted message.
+type mitmDataMessage struct {
+	IV, Ciphertext []byte
+}
+
+// mallory sits between Alice and Bob and sees every message. Each hook returns
+// the message to deliver; fromAlice tells the direction. A nil hook forwards
+// messages unchanged.
+type mallory struct {
+	group func(m mitmGroupMessage, fromAlice bool) mitmGroupMessage
+	key   func(m mitmKeyMessage, fromAlice bool) mitmKeyMessage
+	data  func(m mitmDataMessage, fromAlice bool) mitmDataMessage
+}
+
+func (m *mallory) relayGroup(msg mitmGroupMessage, fromAlice bool) mitmGroupMessage {
+	if m == nil || m.group == nil {
+		return msg
+	}
+	return m.group(msg, fromAlice)
+}
+
+func (m *mallory) relayKey(msg mitmKeyMessage, fromAlice bool) mitmKeyMessage {
+	if m == nil || m.key == nil {
+		return msg
+	}
+	return m.key(msg, fromAlice)
+}
+
+func (m *mallory) relayData(msg mitmDataMessage, fromAlice bool) mitmDataMessage {
+	if m == nil || m.data == nil {
+		return msg
+	}
+	return m.data(msg, fromAlice)
+}
+
+// mitmParty is Alice or Bob.
+type mitmParty struct {
+	group dhgroup.DHScheme
+	dhKey dhgroup.DHKey
+	key   []byte
+}
+
+// join makes the party use the negotiated group p, g and generates its key pair.
+// The rest of the parameters, including the subgroup order and the validation
+// policy, are those of the group the party expects.
+func (party *mitmParty) join(expected *dhgroup.GroupParams, msg mitmGroupMessage) {
+	party.group = &dhgroup.GroupParams{
+		P:          msg.P,
+		G:          msg.G,
+		Q:          expected.Q,
+		Name:       expected.Name,
+		BitSize:    expected.BitSize,
+		Validation: expected.Validation,
+	}
+	dhKey, err := party.group.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	party.dhKey = dhKey
+}
+
+func (party *mitmParty) publicKey() mitmKeyMessage {
+	return mitmKeyMessage{Y: party.dhKey.Public}
+}
+
+func (party *mitmParty) agree(peer mitmKeyMessage) error {
+	s, err := party.group.DH(party.dhKey.Private, peer.Y)
+	if err != nil {
+		return err
+	}
+	party.key = mitmMessageKey(s)
+	return nil
+}
+
+func (party *mitmParty) encrypt(msg []byte) mitmDataMessage {
+	return mitmEncrypt(party.key, msg)
+}
+
+func (party *mitmParty) decrypt(msg mitmDataMessage) ([]byte, error) {
+	return mitmDecrypt(party.key, msg)
+}
+
+// runMITMExchange runs the protocol between Alice and Bob through Mallory.
+// Alice proposes the group and sends messages, Bob echoes each of them back.
+// Both parties derive their keys through DHScheme in the negotiated group.
+// It returns false if either party notices a failure, i.e. DH rejects a public key,
+// Bob can not decrypt a message or Alice gets a wrong echo.
+func runMITMExchange(group dhgroup.DHScheme, m *mallory, messages [][]byte) bool {
+	params := group.DHParams()
+	alice, bob := new(mitmParty), new(mitmParty)
+
+	proposal := m.relayGroup(mitmGroupMessage{P: params.P, G: params.G}, true)
+	bob.join(params, proposal)
+	ack := m.relayGroup(mitmGroupMessage{P: proposal.P, G: proposal.G}, false)
+	alice.join(params, ack)
+
+	A := m.relayKey(alice.publicKey(), true)
+	B := m.relayKey(bob.publicKey(), false)
+	if bob.agree(A) != nil || alice.agree(B) != nil {
+		return false
+	}
+
+	for _, msg := range messages {
+		received, err := bob.decrypt(m.relayData(alice.encrypt(msg), true))
+		if err != nil {
+			return false
+		}
+		echo, err := alice.decrypt(m.relayData(bob.encrypt(received), false))
+		if err != nil || !bytes.Equal(echo, msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// mitmMessageKey derives the AES-128 message key from the shared secret.
+func mitmMessageKey(s *big.Int) []byte {
+	h := sha256.Sum256(s.Bytes())
+	return h[:aes.BlockSize]
+}
+
+func mitmEncrypt(key, msg []byte) mitmDataMessage {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		panic(err)
+	}
+	ct := pkcs7Pad(msg, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ct, ct)
+	return mitmDataMessage{IV: iv, Ciphertext: ct}
+}
+
+func mitmDecrypt(key []byte, msg mitmDataMessage) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.IV) != aes.BlockSize || len(msg.Ciphertext) == 0 || len(msg.Ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("mitm: malformed message")
+	}
+	pt := make([]byte, len(msg.Ciphertext))
+	cipher.NewCBCDecrypter(block, msg.IV).CryptBlocks(pt, msg.Ciphertext)
+	return pkcs7Unpad(pt, aes.BlockSize)
+}
+
+func pkcs7Pad(b []byte, size int) []byte {
+	n := size - len(b)%size
+	return append(append([]byte(nil), b...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs7Unpad(b []byte, size int) ([]byte, error) {
+	if len(b) == 0 || len(b)%size != 0 {
+		return nil, errors.New("mitm: invalid padding")
+	}
+	n := int(b[len(b)-1])
+	if n == 0 || n > size {
+		return nil, errors.New("mitm: invalid padding")
+	}
+	for _, c := range b[len(b)-n:] {
+		if int(c) != n {
+			return nil, errors.New("mitm: invalid padding")
+		}
+	}
+	return b[:len(b)-n], nil
+}
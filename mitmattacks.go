@@ -0,0 +1,100 @@
+package dhpals
+
+import (
+	"math/big"
+)
+
+// runMITMKeyFixingAttack replaces both public keys with p. Then both parties
+// compute s = p^x mod p = 0, Mallory knows the message key and reads the conversation.
+// It returns the messages sent by Alice or nil if the attack was noticed.
+func runMITMKeyFixingAttack(exchange func(*mallory) bool) (messages [][]byte) {
+	var p *big.Int
+	var recorded []mitmDataMessage
+
+	m := &mallory{
+		group: func(msg mitmGroupMessage, fromAlice bool) mitmGroupMessage {
+			p = msg.P
+			return msg
+		},
+		key: func(msg mitmKeyMessage, fromAlice bool) mitmKeyMessage {
+			return mitmKeyMessage{Y: new(big.Int).Set(p)}
+		},
+		data: func(msg mitmDataMessage, fromAlice bool) mitmDataMessage {
+			if fromAlice {
+				recorded = append(recorded, msg)
+			}
+			return msg
+		},
+	}
+
+	if !exchange(m) {
+		return nil
+	}
+	return mitmDecryptAll([]*big.Int{new(big.Int)}, recorded)
+}
+
+// runMITMMaliciousGAttack replaces g with fakeG(p) during the group negotiation,
+// e.g. g = 1, g = p or g = p - 1. Then the shared secret s = g^(ab) belongs to
+// the tiny set {g, g^2, ...} and Mallory finds the message key by trial decryption.
+// It returns the messages sent by Alice or nil if the attack failed.
+func runMITMMaliciousGAttack(exchange func(*mallory) bool, fakeG func(p *big.Int) *big.Int) (messages [][]byte) {
+	var p, g *big.Int
+	var recorded []mitmDataMessage
+
+	m := &mallory{
+		group: func(msg mitmGroupMessage, fromAlice bool) mitmGroupMessage {
+			p, g = msg.P, fakeG(msg.P)
+			return mitmGroupMessage{P: p, G: g}
+		},
+		data: func(msg mitmDataMessage, fromAlice bool) mitmDataMessage {
+			if fromAlice {
+				recorded = append(recorded, msg)
+			}
+			return msg
+		},
+	}
+
+	if !exchange(m) {
+		return nil
+	}
+
+	// Enumerate the powers of g until they repeat.
+	var candidates []*big.Int
+	s := new(big.Int).Mod(g, p)
+	for len(candidates) < 16 {
+		seen := false
+		for _, c := range candidates {
+			if c.Cmp(s) == 0 {
+				seen = true
+				break
+			}
+		}
+		if seen {
+			break
+		}
+		candidates = append(candidates, new(big.Int).Set(s))
+		s.Mul(s, g)
+		s.Mod(s, p)
+	}
+	return mitmDecryptAll(candidates, recorded)
+}
+
+// mitmDecryptAll returns the decrypted messages under the first candidate shared secret
+// that decrypts all of them with valid padding.
+func mitmDecryptAll(candidates []*big.Int, recorded []mitmDataMessage) [][]byte {
+	for _, s := range candidates {
+		key := mitmMessageKey(s)
+		messages := make([][]byte, 0, len(recorded))
+		for _, msg := range recorded {
+			pt, err := mitmDecrypt(key, msg)
+			if err != nil {
+				break
+			}
+			messages = append(messages, pt)
+		}
+		if len(messages) == len(recorded) {
+			return messages
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package dhpals
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestMITMExchange(t *testing.T) {
+	exchange, _ := newMITMOracle(dhgroup.ModP1536)
+	if !exchange(nil) {
+		t.Fatalf("%s: the exchange without Mallory failed", t.Name())
+	}
+
+	// Naive tampering with a public key is noticed.
+	tamper := &mallory{
+		key: func(msg mitmKeyMessage, fromAlice bool) mitmKeyMessage {
+			return mitmKeyMessage{Y: new(big.Int).Add(msg.Y, Big1)}
+		},
+	}
+	if exchange(tamper) {
+		t.Fatalf("%s: the exchange with a tampered public key succeeded", t.Name())
+	}
+}
+
+func TestMITMKeyFixingAttack(t *testing.T) {
+	exchange, isMessagesCorrect := newMITMOracle(dhgroup.ModP1536)
+
+	messages := runMITMKeyFixingAttack(exchange)
+	if !isMessagesCorrect(messages) {
+		t.Fatalf("%s: wrong messages were recovered in the key-fixing attack", t.Name())
+	}
+}
+
+func TestMITMMaliciousGAttack(t *testing.T) {
+	tests := []struct {
+		name  string
+		fakeG func(p *big.Int) *big.Int
+	}{
+		{"g = 1", func(p *big.Int) *big.Int { return big.NewInt(1) }},
+		{"g = p", func(p *big.Int) *big.Int { return new(big.Int).Set(p) }},
+		{"g = p - 1", func(p *big.Int) *big.Int { return new(big.Int).Sub(p, Big1) }},
+	}
+
+	for _, tt := range tests {
+		exchange, isMessagesCorrect := newMITMOracle(dhgroup.ModP1536)
+
+		messages := runMITMMaliciousGAttack(exchange, tt.fakeG)
+		if !isMessagesCorrect(messages) {
+			t.Errorf("%s: %s: wrong messages were recovered", t.Name(), tt.name)
+		}
+	}
+}
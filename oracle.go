@@ -26,6 +26,8 @@ func mixKey(k []byte) []byte {
 	return mac.Sum(nil)
 }
 
+// newDHOracle emulates a server with a static DH key in the group id.
+// It keeps the stripped encoding of the shared secret the exercises rely on.
 func newDHOracle(id dhgroup.ID) (
 	dh func(publicKey *big.Int) []byte,
 	isKeyCorrect func([]byte) bool,
@@ -55,6 +57,336 @@ func newDHOracle(id dhgroup.ID) (
 	return
 }
 
+// newValidatingDHOracle works as newDHOracle, but the oracle validates public keys
+// according to the given policy and panics on invalid ones. It derives the key from
+// the same stripped encoding of the shared secret, so it can replace newDHOracle in the attacks.
+func newValidatingDHOracle(id dhgroup.ID, policy dhgroup.ValidationPolicy) (
+	dh func(publicKey *big.Int) []byte,
+	isKeyCorrect func([]byte) bool,
+	getPublicKey func() *big.Int,
+) {
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	return newSchemeDHOracle(dhgroup.WithValidation(dhGroup, policy), (*big.Int).Bytes)
+}
+
+// newShortExponentDHOracle works as newDHOracle, but the private key is at most bits bits long
+// and the shared secret is encoded with the fixed length before the key derivation.
+func newShortExponentDHOracle(id dhgroup.ID, bits int) (
+	dh func(publicKey *big.Int) []byte,
+	isKeyCorrect func([]byte) bool,
+	getPublicKey func() *big.Int,
+) {
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	dhGroup = dhgroup.WithShortExponent(dhGroup, bits)
+	return newSchemeDHOracle(dhGroup, func(k *big.Int) []byte {
+		return dhgroup.SharedSecretBytes(dhGroup, k)
+	})
+}
+
+// newSchemeDHOracle emulates a server with a static key in dhGroup.
+// encode converts the shared secret to bytes before the key derivation.
+func newSchemeDHOracle(dhGroup dhgroup.DHScheme, encode func(*big.Int) []byte) (
+	dh func(publicKey *big.Int) []byte,
+	isKeyCorrect func([]byte) bool,
+	getPublicKey func() *big.Int,
+) {
+
+	dhKey, _ := dhGroup.GenerateKey(rand.Reader)
+
+	dh = func(publicKey *big.Int) []byte {
+		sharedKey, err := dhGroup.DH(dhKey.Private, publicKey)
+		if err != nil {
+			panic(err)
+		}
+		return mixKey(encode(sharedKey))
+	}
+
+	isKeyCorrect = func(key []byte) bool {
+		return bytes.Equal(dhKey.Private.Bytes(), key)
+	}
+
+	getPublicKey = func() *big.Int {
+		return dhKey.Public
+	}
+
+	return
+}
+
+// newRaccoonOracle emulates a server with a static DH key that, like TLS-DH before 1.3,
+// strips leading zero bytes of the shared secret before the key derivation.
+// The key derivation time depends on the length of the stripped secret, so
+// dh returns the derived key and the simulated processing time in abstract units.
+// getPublicKeys returns the server public key and the client public key of
+// an observed handshake; isSecretCorrect checks the shared secret of that handshake.
+func newRaccoonOracle(dhGroup dhgroup.DHScheme) (
+	dh func(publicKey *big.Int) (key []byte, elapsed int),
+	getPublicKeys func() (server, client *big.Int),
+	isSecretCorrect func([]byte) bool,
+) {
+
+	server, _ := dhGroup.GenerateKey(rand.Reader)
+	client, _ := dhGroup.GenerateKey(rand.Reader)
+
+	dh = func(publicKey *big.Int) ([]byte, int) {
+		sharedKey, err := dhGroup.DH(server.Private, publicKey)
+		if err != nil {
+			panic(err)
+		}
+		k := sharedKey.Bytes()
+		return mixKey(k), len(k)
+	}
+
+	getPublicKeys = func() (*big.Int, *big.Int) {
+		return server.Public, client.Public
+	}
+
+	isSecretCorrect = func(secret []byte) bool {
+		zz, _ := dhGroup.DH(client.Private, server.Public)
+		return bytes.Equal(dhgroup.SharedSecretBytes(dhGroup, zz), secret)
+	}
+
+	return
+}
+
+// newDSAOracle signs messages with a static key of the group using DSA and SHA-256.
+// The nonces are taken from the given source, so it can be deliberately broken.
+func newDSAOracle(id dhgroup.ID, nonce dhgroup.NonceSource) (
+	sign func(msg []byte) (r, s *big.Int),
+	getPublicKey func() *big.Int,
+	isKeyCorrect func([]byte) bool,
+) {
+
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	key, _ := dhGroup.GenerateKey(rand.Reader)
+
+	sign = func(msg []byte) (*big.Int, *big.Int) {
+		h := sha256.Sum256(msg)
+		r, s, err := dhGroup.DHParams().Sign(key.Private, h[:], nonce)
+		if err != nil {
+			panic(err)
+		}
+		return r, s
+	}
+
+	getPublicKey = func() *big.Int {
+		return key.Public
+	}
+
+	isKeyCorrect = func(k []byte) bool {
+		return bytes.Equal(key.Private.Bytes(), k)
+	}
+
+	return
+}
+
+// newElGamalOracle emulates a service that decrypts ElGamal ciphertexts with a static key
+// and returns the plaintext. It does not check that C1 belongs to the prime-order subgroup.
+func newElGamalOracle(id dhgroup.ID) (
+	decrypt func(c *dhgroup.ElGamalCiphertext) *big.Int,
+	getPublicKey func() *big.Int,
+	isKeyCorrect func([]byte) bool,
+) {
+
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	key, _ := dhGroup.GenerateKey(rand.Reader)
+
+	decrypt = func(c *dhgroup.ElGamalCiphertext) *big.Int {
+		m, err := dhgroup.ElGamalDecrypt(dhGroup, key.Private, c)
+		if err != nil {
+			panic(err)
+		}
+		return m
+	}
+
+	getPublicKey = func() *big.Int {
+		return key.Public
+	}
+
+	isKeyCorrect = func(k []byte) bool {
+		return bytes.Equal(key.Private.Bytes(), k)
+	}
+
+	return
+}
+
+// newElGamalChallengeOracle emulates a service that decrypts any ElGamal ciphertext
+// except the challenge, an encryption of a secret message under its public key.
+func newElGamalChallengeOracle(id dhgroup.ID) (
+	decrypt func(c *dhgroup.ElGamalCiphertext) (*big.Int, error),
+	getChallenge func() (publicKey *big.Int, challenge *dhgroup.ElGamalCiphertext),
+	isMessageCorrect func(*big.Int) bool,
+) {
+
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	key, _ := dhGroup.GenerateKey(rand.Reader)
+
+	p := dhGroup.DHParams().P
+	m, _ := rand.Int(rand.Reader, new(big.Int).Sub(p, Big1))
+	m.Add(m, Big1)
+	challenge, err := dhgroup.ElGamalEncrypt(dhGroup, rand.Reader, key.Public, m)
+	if err != nil {
+		panic(err)
+	}
+
+	decrypt = func(c *dhgroup.ElGamalCiphertext) (*big.Int, error) {
+		if c.C1.Cmp(challenge.C1) == 0 && c.C2.Cmp(challenge.C2) == 0 {
+			return nil, errors.New("elgamal: decryption of the challenge is not allowed")
+		}
+		return dhgroup.ElGamalDecrypt(dhGroup, key.Private, c)
+	}
+
+	getChallenge = func() (*big.Int, *dhgroup.ElGamalCiphertext) {
+		return key.Public, challenge
+	}
+
+	isMessageCorrect = func(x *big.Int) bool {
+		return x.Cmp(m) == 0
+	}
+
+	return
+}
+
+// newMITMOracle lets the caller play Mallory in the protocol simulated by runMITMExchange.
+// Alice sends secret messages to Bob in the group id; exchange reports whether the
+// parties completed the protocol without noticing the attack.
+func newMITMOracle(id dhgroup.ID) (
+	exchange func(m *mallory) bool,
+	isMessagesCorrect func([][]byte) bool,
+) {
+
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+
+	messages := make([][]byte, 3)
+	for i := range messages {
+		messages[i] = make([]byte, 20+11*i)
+		if _, err := rand.Read(messages[i]); err != nil {
+			panic(err)
+		}
+	}
+
+	exchange = func(m *mallory) bool {
+		return runMITMExchange(dhGroup, m, messages)
+	}
+
+	isMessagesCorrect = func(recovered [][]byte) bool {
+		if len(recovered) != len(messages) {
+			return false
+		}
+		for i := range messages {
+			if !bytes.Equal(recovered[i], messages[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return
+}
+
+// newSRPServerOracle emulates an SRP-6a server with a single user that has a random password.
+// The server does not check A mod N != 0. hello starts a session and login finishes it;
+// isKeyCorrect checks the session key of the last authenticated session.
+func newSRPServerOracle(id dhgroup.ID, identity string) (
+	hello func(identity string, A *big.Int) (salt []byte, B *big.Int),
+	login func(m1 []byte) (m2 []byte, ok bool),
+	isKeyCorrect func([]byte) bool,
+) {
+
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	srp := &dhgroup.SRP{Group: dhGroup}
+
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		panic(err)
+	}
+	verifier, err := srp.NewVerifier(rand.Reader, identity, password)
+	if err != nil {
+		panic(err)
+	}
+
+	var server *dhgroup.SRPServer
+	var A *big.Int
+	var key []byte
+
+	hello = func(user string, clientPublic *big.Int) ([]byte, *big.Int) {
+		if user != identity {
+			panic("unknown user")
+		}
+		server, err = srp.NewServer(rand.Reader, verifier)
+		if err != nil {
+			panic(err)
+		}
+		server.NoPublicKeyCheck = true
+		A = clientPublic
+		return server.Salt(), server.B
+	}
+
+	login = func(m1 []byte) ([]byte, bool) {
+		if server == nil {
+			return nil, false
+		}
+		m2, err := server.Verify(A, m1)
+		if err != nil {
+			return nil, false
+		}
+		key = server.Key()
+		return m2, true
+	}
+
+	isKeyCorrect = func(k []byte) bool {
+		return key != nil && bytes.Equal(key, k)
+	}
+
+	return
+}
+
+// simplifiedSRPMAC returns HMAC-SHA256(K, salt) for K = SHA256(S) as in the simplified SRP.
+func simplifiedSRPMAC(S *big.Int, salt []byte) []byte {
+	k := sha256.Sum256(S.Bytes())
+	mac := hmac.New(sha256.New, k[:])
+	mac.Write(salt)
+	return mac.Sum(nil)
+}
+
+// newSimplifiedSRPOracle emulates a client of the simplified SRP, where the server sends
+// salt, B = g^b and u, and the client proves the knowledge of the password with
+// HMAC-SHA256(K, salt) for K = SHA256(B^(a + u*x)) and x = SHA256(salt | password).
+// The password is chosen from the dictionary. login connects the client to a server.
+func newSimplifiedSRPOracle(id dhgroup.ID, dictionary []string) (
+	login func(salt []byte, B, u *big.Int) (A *big.Int, mac []byte),
+	isPasswordCorrect func(string) bool,
+) {
+
+	var dhGroup, _ = dhgroup.GroupForGroupID(id)
+	p := dhGroup.DHParams().P
+
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(dictionary))))
+	if err != nil {
+		panic(err)
+	}
+	password := dictionary[i.Int64()]
+
+	login = func(salt []byte, B, u *big.Int) (*big.Int, []byte) {
+		key, err := dhGroup.GenerateKey(rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		h := sha256.Sum256(append(append([]byte(nil), salt...), password...))
+		x := new(big.Int).SetBytes(h[:])
+
+		exp := new(big.Int).Mul(u, x)
+		exp.Add(exp, key.Private)
+		S := new(big.Int).Exp(B, exp, p)
+		return key.Public, simplifiedSRPMAC(S, salt)
+	}
+
+	isPasswordCorrect = func(w string) bool {
+		return w == password
+	}
+
+	return
+}
+
 func newECDHAttackOracle(curve elliptic.Curve) (
 	ecdh func(x, y *big.Int) []byte,
 	isKeyCorrect func([]byte) bool,
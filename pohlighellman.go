@@ -0,0 +1,262 @@
+package dhpals
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+	"github.com/dnkolegov/dhpals/elliptic"
+)
+
+// Element is an element of a DLPGroup. Its dynamic type is defined by the group.
+type Element interface{}
+
+// DLPGroup is a finite abelian group, written multiplicatively, in which
+// the discrete logarithms are computed.
+type DLPGroup interface {
+	// Identity returns the neutral element.
+	Identity() Element
+	// Op returns a*b.
+	Op(a, b Element) Element
+	// Inverse returns a^-1.
+	Inverse(a Element) Element
+	// Exp returns a^k for k >= 0.
+	Exp(a Element, k *big.Int) Element
+	// Equal reports whether a = b.
+	Equal(a, b Element) bool
+	// Key returns a string that identifies the element. It is used in hash tables.
+	Key(a Element) string
+}
+
+// ModPGroup is the multiplicative group Z_p^*. Its elements are *big.Int.
+type ModPGroup struct {
+	P *big.Int
+}
+
+func (grp ModPGroup) Identity() Element {
+	return big.NewInt(1)
+}
+
+func (grp ModPGroup) Op(a, b Element) Element {
+	c := new(big.Int).Mul(a.(*big.Int), b.(*big.Int))
+	return c.Mod(c, grp.P)
+}
+
+func (grp ModPGroup) Inverse(a Element) Element {
+	return new(big.Int).ModInverse(a.(*big.Int), grp.P)
+}
+
+func (grp ModPGroup) Exp(a Element, k *big.Int) Element {
+	return new(big.Int).Exp(a.(*big.Int), k, grp.P)
+}
+
+func (grp ModPGroup) Equal(a, b Element) bool {
+	return a.(*big.Int).Cmp(b.(*big.Int)) == 0
+}
+
+func (grp ModPGroup) Key(a Element) string {
+	return string(a.(*big.Int).Bytes())
+}
+
+// CurvePoint is an element of a CurveGroup. The point at infinity is (0, 0).
+type CurvePoint struct {
+	X, Y *big.Int
+}
+
+// CurveGroup is the group of points of an elliptic curve. Its elements are CurvePoint.
+type CurveGroup struct {
+	Curve elliptic.Curve
+}
+
+func (grp CurveGroup) Identity() Element {
+	return CurvePoint{new(big.Int), new(big.Int)}
+}
+
+func (grp CurveGroup) Op(a, b Element) Element {
+	pa, pb := a.(CurvePoint), b.(CurvePoint)
+	x, y := grp.Curve.Add(pa.X, pa.Y, pb.X, pb.Y)
+	return CurvePoint{x, y}
+}
+
+func (grp CurveGroup) Inverse(a Element) Element {
+	pa := a.(CurvePoint)
+	x, y := elliptic.Inverse(grp.Curve, pa.X, pa.Y)
+	return CurvePoint{x, y}
+}
+
+func (grp CurveGroup) Exp(a Element, k *big.Int) Element {
+	if k.Sign() == 0 {
+		return grp.Identity()
+	}
+	pa := a.(CurvePoint)
+	x, y := grp.Curve.ScalarMult(pa.X, pa.Y, k.Bytes())
+	return CurvePoint{x, y}
+}
+
+func (grp CurveGroup) Equal(a, b Element) bool {
+	pa, pb := a.(CurvePoint), b.(CurvePoint)
+	return pa.X.Cmp(pb.X) == 0 && pa.Y.Cmp(pb.Y) == 0
+}
+
+func (grp CurveGroup) Key(a Element) string {
+	pa := a.(CurvePoint)
+	return string(elliptic.Marshal(grp.Curve, pa.X, pa.Y))
+}
+
+// DLPSolver finds x in [0, n) such that g^x = y, where g has prime order n.
+type DLPSolver func(grp DLPGroup, g, y Element, n *big.Int) (*big.Int, error)
+
+// ExhaustiveSearch is a DLPSolver that tries all the exponents.
+func ExhaustiveSearch(grp DLPGroup, g, y Element, n *big.Int) (*big.Int, error) {
+	e := grp.Identity()
+	for x := new(big.Int); x.Cmp(n) < 0; x.Add(x, Big1) {
+		if grp.Equal(e, y) {
+			return x, nil
+		}
+		e = grp.Op(e, g)
+	}
+	return nil, errors.New("exhaustive search: no solution")
+}
+
+// BabyStepGiantStep is a DLPSolver that uses Shanks' baby-step giant-step algorithm.
+// It needs memory for sqrt(n) elements.
+func BabyStepGiantStep(grp DLPGroup, g, y Element, n *big.Int) (*big.Int, error) {
+	m := new(big.Int).Sqrt(n)
+	m.Add(m, Big1)
+	if m.BitLen() > 32 {
+		return nil, errors.New("bsgs: the order is too large")
+	}
+	steps := m.Int64()
+
+	// Baby steps: g^j for j < m.
+	table := make(map[string]int64, steps)
+	e := grp.Identity()
+	for j := int64(0); j < steps; j++ {
+		if _, ok := table[grp.Key(e)]; !ok {
+			table[grp.Key(e)] = j
+		}
+		e = grp.Op(e, g)
+	}
+
+	// Giant steps: y*g^(-m*i) for i < m.
+	gm := grp.Inverse(e)
+	e = y
+	for i := int64(0); i < steps; i++ {
+		if j, ok := table[grp.Key(e)]; ok {
+			x := new(big.Int).Mul(big.NewInt(i), m)
+			x.Add(x, big.NewInt(j))
+			return x.Mod(x, n), nil
+		}
+		e = grp.Op(e, gm)
+	}
+	return nil, errors.New("bsgs: no solution")
+}
+
+// PohligHellman finds x such that g^x = y in grp, given the order n of g (or a multiple
+// of it, e.g. the order of the group) and its factorization.
+// For every prime power l^e dividing the order of g, it finds x mod l^e one digit at
+// a time with solve in the subgroup of order l and combines the parts with crt.
+func PohligHellman(grp DLPGroup, g, y Element, n *big.Int, factors []dhgroup.Factor, solve DLPSolver) (*big.Int, error) {
+	for _, f := range factors {
+		if f.Exp < 1 {
+			return nil, fmt.Errorf("pohlig-hellman: invalid exponent of %d", f.Prime)
+		}
+	}
+	if factorsProduct(factors).Cmp(n) != 0 {
+		return nil, errors.New("pohlig-hellman: the factorization does not match the order")
+	}
+	if !grp.Equal(grp.Exp(g, n), grp.Identity()) {
+		return nil, errors.New("pohlig-hellman: the order of g does not divide n")
+	}
+
+	n, factors = reduceOrder(grp, g, n, factors)
+	if len(factors) == 0 {
+		// g is the identity.
+		if !grp.Equal(y, g) {
+			return nil, errors.New("pohlig-hellman: y is not in the subgroup generated by g")
+		}
+		return new(big.Int), nil
+	}
+
+	var A, N []*big.Int
+	for _, f := range factors {
+		x, le, err := pohligHellmanPrimePower(grp, g, y, n, f, solve)
+		if err != nil {
+			return nil, err
+		}
+		A = append(A, x)
+		N = append(N, le)
+	}
+	x, _, err := crt(A, N)
+	if err != nil {
+		return nil, err
+	}
+	if !grp.Equal(grp.Exp(g, x), y) {
+		return nil, errors.New("pohlig-hellman: y is not in the subgroup generated by g")
+	}
+	return x, nil
+}
+
+// pohligHellmanPrimePower finds x mod l^e, where l^e is the largest power of l dividing
+// the order n of g. It returns x mod l^e and l^e.
+func pohligHellmanPrimePower(grp DLPGroup, g, y Element, n *big.Int, f dhgroup.Factor, solve DLPSolver) (*big.Int, *big.Int, error) {
+	l := f.Prime
+	le := new(big.Int).Exp(l, big.NewInt(int64(f.Exp)), nil)
+	h := new(big.Int).Div(n, le)
+	gi := grp.Exp(g, h)
+	yi := grp.Exp(y, h)
+
+	// gamma = gi^(l^(e-1)) has order l.
+	gamma := grp.Exp(gi, new(big.Int).Div(le, l))
+	giInv := grp.Inverse(gi)
+
+	x := new(big.Int)
+	lk := big.NewInt(1)
+	for k := 0; k < f.Exp; k++ {
+		// hk = (gi^-x * yi)^(l^(e-1-k)) = gamma^dk.
+		hk := grp.Op(grp.Exp(giInv, x), yi)
+		hk = grp.Exp(hk, new(big.Int).Exp(l, big.NewInt(int64(f.Exp-1-k)), nil))
+
+		dk, err := solve(grp, gamma, hk, l)
+		if err != nil {
+			return nil, nil, err
+		}
+		x.Add(x, dk.Mul(dk, lk))
+		lk.Mul(lk, l)
+	}
+	return x, le, nil
+}
+
+// factorsProduct returns the number with the given factorization.
+func factorsProduct(factors []dhgroup.Factor) *big.Int {
+	n := big.NewInt(1)
+	for _, f := range factors {
+		n.Mul(n, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exp)), nil))
+	}
+	return n
+}
+
+// reduceOrder returns the order of g and its factorization given the factorization
+// of a multiple n of the order.
+func reduceOrder(grp DLPGroup, g Element, n *big.Int, factors []dhgroup.Factor) (*big.Int, []dhgroup.Factor) {
+	n = new(big.Int).Set(n)
+	id := grp.Identity()
+
+	var order []dhgroup.Factor
+	for _, f := range factors {
+		e := f.Exp
+		for e > 0 {
+			m := new(big.Int).Div(n, f.Prime)
+			if !grp.Equal(grp.Exp(g, m), id) {
+				break
+			}
+			n = m
+			e--
+		}
+		if e > 0 {
+			order = append(order, dhgroup.Factor{Prime: f.Prime, Exp: e})
+		}
+	}
+	return n, order
+}
@@ -0,0 +1,180 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+	"github.com/dnkolegov/dhpals/elliptic"
+)
+
+// affineCurve implements the group law of elliptic.CurveParams in affine coordinates,
+// so that the tests do not depend on the lab implementation.
+type affineCurve struct {
+	*elliptic.CurveParams
+}
+
+func (c affineCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p := c.P
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+
+	var m *big.Int
+	if x1.Cmp(x2) == 0 {
+		if s := new(big.Int).Add(y1, y2); s.Mod(s, p).Sign() == 0 {
+			return new(big.Int), new(big.Int)
+		}
+		// m = (3*x1^2 + a) / (2*y1).
+		m = new(big.Int).Mul(x1, x1)
+		m.Mul(m, big.NewInt(3))
+		m.Add(m, c.A)
+		m.Mul(m, new(big.Int).ModInverse(new(big.Int).Lsh(y1, 1), p))
+	} else {
+		// m = (y2 - y1) / (x2 - x1).
+		m = new(big.Int).Sub(y2, y1)
+		d := new(big.Int).Sub(x2, x1)
+		m.Mul(m, d.ModInverse(d.Mod(d, p), p))
+	}
+	m.Mod(m, p)
+
+	x := new(big.Int).Mul(m, m)
+	x.Sub(x, x1)
+	x.Sub(x, x2)
+	x.Mod(x, p)
+	y := new(big.Int).Sub(x1, x)
+	y.Mul(y, m)
+	y.Sub(y, y1)
+	y.Mod(y, p)
+	return x, y
+}
+
+func (c affineCurve) Double(x, y *big.Int) (*big.Int, *big.Int) {
+	return c.Add(x, y, x, y)
+}
+
+func (c affineCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	rx, ry := new(big.Int), new(big.Int)
+	n := new(big.Int).SetBytes(k)
+	for i := n.BitLen() - 1; i >= 0; i-- {
+		rx, ry = c.Double(rx, ry)
+		if n.Bit(i) == 1 {
+			rx, ry = c.Add(rx, ry, x, y)
+		}
+	}
+	return rx, ry
+}
+
+func (c affineCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.Gx, c.Gy, k)
+}
+
+func TestPohligHellmanModP(t *testing.T) {
+	group, _, err := dhgroup.GenerateWeakGroup(mrand.New(mrand.NewSource(21)),
+		dhgroup.WeakGroupConfig{QBits: 24, SmoothnessBound: 1 << 12, SmallFactors: 4})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	factors, _ := group.CofactorFactors()
+	factors = append(factors, dhgroup.Factor{Prime: group.Q, Exp: 1})
+	pm1 := new(big.Int).Sub(group.P, Big1)
+	grp := ModPGroup{group.P}
+
+	for i := 0; i < 3; i++ {
+		g, _ := rand.Int(rand.Reader, pm1)
+		g.Add(g, Big1)
+		x, _ := rand.Int(rand.Reader, pm1)
+		y := new(big.Int).Exp(g, x, group.P)
+
+		xx, err := PohligHellman(grp, g, y, pm1, factors, BabyStepGiantStep)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if new(big.Int).Exp(g, xx, group.P).Cmp(y) != 0 {
+			t.Fatalf("%s: g = %d, y = %d: wrong logarithm %d", t.Name(), g, y, xx)
+		}
+	}
+
+	// 10 generates Z_p^* of order 2 * 3 * 5 * 7 * 100169.
+	p := big.NewInt(21035491)
+	g := big.NewInt(10)
+	x := big.NewInt(12345678)
+	y := new(big.Int).Exp(g, x, p)
+	n := new(big.Int).Sub(p, Big1)
+	factors = []dhgroup.Factor{
+		{Prime: big.NewInt(2), Exp: 1},
+		{Prime: big.NewInt(3), Exp: 1},
+		{Prime: big.NewInt(5), Exp: 1},
+		{Prime: big.NewInt(7), Exp: 1},
+		{Prime: big.NewInt(100169), Exp: 1},
+	}
+	for _, solve := range []DLPSolver{ExhaustiveSearch, BabyStepGiantStep} {
+		xx, err := PohligHellman(ModPGroup{p}, g, y, n, factors, solve)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if xx.Cmp(x) != 0 {
+			t.Fatalf("%s: want %d, got %d", t.Name(), x, xx)
+		}
+	}
+
+	if _, err := PohligHellman(ModPGroup{p}, g, y, n, factors[1:], BabyStepGiantStep); err == nil {
+		t.Errorf("%s: expected an error for a wrong factorization", t.Name())
+	}
+}
+
+func TestPohligHellmanPrimePowers(t *testing.T) {
+	// p - 1 = 2^5 * 3^3 * 5^2 * 7, and 17 generates Z_p^*.
+	p := big.NewInt(151201)
+	g := big.NewInt(17)
+	factors := []dhgroup.Factor{
+		{Prime: big.NewInt(2), Exp: 5},
+		{Prime: big.NewInt(3), Exp: 3},
+		{Prime: big.NewInt(5), Exp: 2},
+		{Prime: big.NewInt(7), Exp: 1},
+	}
+	n := new(big.Int).Sub(p, Big1)
+	for _, x := range []int64{0, 1, 2, 1000, 151199} {
+		y := new(big.Int).Exp(g, big.NewInt(x), p)
+		xx, err := PohligHellman(ModPGroup{p}, g, y, n, factors, ExhaustiveSearch)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if xx.Int64() != x {
+			t.Fatalf("%s: want %d, got %d", t.Name(), x, xx)
+		}
+	}
+}
+
+func TestPohligHellmanCurve(t *testing.T) {
+	// The order of the curve is 2 * 5 * 29 * 607 * 28349 * 29287.
+	curve := affineCurve{elliptic.P48().Params()}
+	grp := CurveGroup{curve}
+	factors := []dhgroup.Factor{
+		{Prime: big.NewInt(2), Exp: 1},
+		{Prime: big.NewInt(5), Exp: 1},
+		{Prime: big.NewInt(29), Exp: 1},
+		{Prime: big.NewInt(607), Exp: 1},
+		{Prime: big.NewInt(28349), Exp: 1},
+		{Prime: big.NewInt(29287), Exp: 1},
+	}
+	g := CurvePoint{curve.Gx, curve.Gy}
+
+	for i := 0; i < 3; i++ {
+		x, _ := rand.Int(rand.Reader, curve.N)
+		y := grp.Exp(g, x)
+
+		xx, err := PohligHellman(grp, g, y, curve.N, factors, BabyStepGiantStep)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if !grp.Equal(grp.Exp(g, xx), y) {
+			t.Fatalf("%s: x = %d: wrong logarithm %d", t.Name(), x, xx)
+		}
+	}
+}
@@ -0,0 +1,739 @@
+package dhpals
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+	"github.com/dnkolegov/dhpals/elliptic"
+)
+
+// Primality testing, proving and provable prime generation.
+//
+// BPSW is the Baillie-PSW probable prime test, which has no known counterexamples and
+// is proven to be correct below 2^64. Larger primes are proven with Pocklington's theorem:
+// let N-1 = F*R, where F is completely factored, and for every prime q | F let there be
+// a_q such that a_q^(N-1) = 1 mod N and gcd(a_q^((N-1)/q) - 1, N) = 1. Then every prime
+// factor of N is 1 mod F, so N is prime if F > sqrt(N). The factors q are proven
+// recursively, and the witnesses a_q make up a certificate that is verified quickly.
+// If F = N-1, this is the Lucas test and the certificate is a Pratt certificate.
+//
+// If N+1 is easier to factor, Morrison's theorem is used instead: let N+1 = F*R and
+// (D/N) = -1, and for every prime q | F let there be a Lucas sequence U with the
+// discriminant D such that U_(N+1) = 0 mod N and gcd(U_((N+1)/q), N) = 1. Then every
+// prime factor of N is ±1 mod F, so N is prime if F > sqrt(N)+1.
+
+// bpswBound is the bound below which the Baillie-PSW test is proven to be correct.
+var bpswBound = new(big.Int).Lsh(Big1, 64)
+
+// BPSW reports whether n passes the Baillie-PSW test: the strong probable prime test
+// to base 2 and the strong Lucas probable prime test with Selfridge's parameters.
+func BPSW(n *big.Int) bool {
+	if n.Cmp(Big2) < 0 {
+		return false
+	}
+	for _, p := range []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+		if n.Cmp(big.NewInt(p)) == 0 {
+			return true
+		}
+		if divides(big.NewInt(p), n) {
+			return false
+		}
+	}
+	return strongProbablePrime(n, Big2) && strongLucasProbablePrime(n)
+}
+
+// strongProbablePrime is the Miller-Rabin test of the odd n > 2 to base a:
+// for n-1 = d*2^s with odd d, either a^d = 1 or a^(d*2^r) = -1 mod n for some r < s.
+func strongProbablePrime(n, a *big.Int) bool {
+	nm1 := new(big.Int).Sub(n, Big1)
+	s := trailingZeroBits(nm1)
+	d := new(big.Int).Rsh(nm1, s)
+
+	x := new(big.Int).Exp(a, d, n)
+	if x.Cmp(Big1) == 0 || x.Cmp(nm1) == 0 {
+		return true
+	}
+	for r := uint(1); r < s; r++ {
+		x.Mul(x, x)
+		x.Mod(x, n)
+		if x.Cmp(nm1) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// strongLucasProbablePrime is the strong Lucas test of the odd n > 2 that is not
+// divisible by small primes. D is the first of 5, -7, 9, -11, ... with (D/n) = -1,
+// P = 1 and Q = (1-D)/4. For n+1 = d*2^s with odd d, either U_d = 0 or
+// V_(d*2^r) = 0 mod n for some r < s.
+func strongLucasProbablePrime(n *big.Int) bool {
+	// There is no D for squares.
+	if r := new(big.Int).Sqrt(n); r.Mul(r, r).Cmp(n) == 0 {
+		return false
+	}
+	d := int64(5)
+	for {
+		j := big.Jacobi(big.NewInt(d), n)
+		if j == -1 {
+			break
+		}
+		if j == 0 && new(big.Int).Abs(big.NewInt(d)).Cmp(n) != 0 {
+			return false
+		}
+		if d > 0 {
+			d = -d - 2
+		} else {
+			d = -d + 2
+		}
+	}
+	D := big.NewInt(d)
+	Q := big.NewInt((1 - d) / 4)
+
+	np1 := new(big.Int).Add(n, Big1)
+	s := trailingZeroBits(np1)
+	k := new(big.Int).Rsh(np1, s)
+
+	// half returns x/2 mod n.
+	half := func(x *big.Int) {
+		if x.Bit(0) == 1 {
+			x.Add(x, n)
+		}
+		x.Rsh(x, 1)
+		x.Mod(x, n)
+	}
+	// U_1 = 1, V_1 = P = 1. Doubling: U_2j = U_j*V_j, V_2j = V_j^2 - 2Q^j;
+	// increment: U_(j+1) = (P*U_j + V_j)/2, V_(j+1) = (D*U_j + P*V_j)/2.
+	u, v := big.NewInt(1), big.NewInt(1)
+	qk := new(big.Int).Mod(Q, n)
+	t := new(big.Int)
+	for i := k.BitLen() - 2; i >= 0; i-- {
+		u.Mul(u, v)
+		u.Mod(u, n)
+		v.Mul(v, v)
+		v.Sub(v, t.Lsh(qk, 1))
+		v.Mod(v, n)
+		qk.Mul(qk, qk)
+		qk.Mod(qk, n)
+		if k.Bit(i) == 1 {
+			t.Mul(D, u)
+			u.Add(u, v)
+			v.Add(v, t)
+			half(u)
+			half(v)
+			qk.Mul(qk, Q)
+			qk.Mod(qk, n)
+		}
+	}
+	if u.Sign() == 0 || v.Sign() == 0 {
+		return true
+	}
+	for r := uint(1); r < s; r++ {
+		v.Mul(v, v)
+		v.Sub(v, t.Lsh(qk, 1))
+		v.Mod(v, n)
+		if v.Sign() == 0 {
+			return true
+		}
+		qk.Mul(qk, qk)
+		qk.Mod(qk, n)
+	}
+	return false
+}
+
+// PrimeCertificate proves that N is prime. If N < 2^64, it is checked with BPSW and
+// Factors is empty. Otherwise Factors are the prime factors of N-1 with the Pocklington
+// witnesses, and their product is greater than sqrt(N), or, if D is set, the prime
+// factors of N+1 with the Lucas witnesses, and their product is greater than sqrt(N)+1.
+type PrimeCertificate struct {
+	N       *big.Int
+	Factors []PocklingtonFactor
+	// D is the discriminant of the Lucas sequences if Factors divide N+1; nil if they divide N-1.
+	D *big.Int
+}
+
+// PocklingtonFactor is a prime power Prime^Exp dividing N-1 with the witness a such that
+// a^(N-1) = 1 mod N and gcd(a^((N-1)/Prime) - 1, N) = 1. For the factors of N+1, the
+// witness is P of the Lucas sequence U with P^2 - 4Q = D such that U_(N+1) = 0 mod N
+// and gcd(U_((N+1)/Prime), N) = 1.
+type PocklingtonFactor struct {
+	Prime   *big.Int
+	Exp     int
+	Witness *big.Int
+	// Certificate proves that Prime is prime. It is nil for Prime < 2^64.
+	Certificate *PrimeCertificate
+}
+
+// Verify checks the certificate.
+func (c *PrimeCertificate) Verify() error {
+	return c.verify(make(map[string]bool))
+}
+
+// verify checks the certificate and the certificates of the factors. Every factor q
+// must be less than N and divide N-1, so the recursion ends; verified holds the numbers
+// whose certificates are already checked.
+func (c *PrimeCertificate) verify(verified map[string]bool) error {
+	n := c.N
+	if n == nil || n.Cmp(Big2) < 0 {
+		return errors.New("primality: invalid number")
+	}
+	if len(c.Factors) == 0 {
+		if n.Cmp(bpswBound) >= 0 {
+			return fmt.Errorf("primality: no factors of %d-1", n)
+		}
+		if !BPSW(n) {
+			return fmt.Errorf("primality: %d is composite", n)
+		}
+		return nil
+	}
+
+	m := new(big.Int).Sub(n, Big1)
+	if c.D != nil {
+		if n.Bit(0) == 0 || big.Jacobi(c.D, n) != -1 {
+			return fmt.Errorf("primality: invalid discriminant for %d", n)
+		}
+		m.Add(n, Big1)
+	}
+	f := big.NewInt(1)
+	for _, pf := range c.Factors {
+		q := pf.Prime
+		if q == nil || pf.Witness == nil || pf.Exp < 1 || q.Cmp(Big2) < 0 {
+			return errors.New("primality: invalid factor")
+		}
+		if q.Cmp(n) >= 0 || !divides(q, m) {
+			return fmt.Errorf("primality: %d does not divide %d", q, m)
+		}
+		if q.Cmp(bpswBound) < 0 {
+			if !BPSW(q) {
+				return fmt.Errorf("primality: %d is composite", q)
+			}
+		} else if pf.Certificate == nil || pf.Certificate.N == nil || pf.Certificate.N.Cmp(q) != 0 {
+			return fmt.Errorf("primality: no certificate for %d", q)
+		} else if !verified[q.String()] {
+			if err := pf.Certificate.verify(verified); err != nil {
+				return err
+			}
+			verified[q.String()] = true
+		}
+
+		if c.D != nil {
+			if err := checkLucasWitness(n, c.D, q, pf.Witness); err != nil {
+				return err
+			}
+		} else {
+			if new(big.Int).Exp(pf.Witness, m, n).Cmp(Big1) != 0 {
+				return fmt.Errorf("primality: %d is composite", n)
+			}
+			e := new(big.Int).Exp(pf.Witness, new(big.Int).Div(m, q), n)
+			if e.Sub(e, Big1).GCD(nil, nil, e, n).Cmp(Big1) != 0 {
+				return fmt.Errorf("primality: invalid witness for %d", q)
+			}
+		}
+		f.Mul(f, new(big.Int).Exp(q, big.NewInt(int64(pf.Exp)), nil))
+	}
+	if !divides(f, m) {
+		return fmt.Errorf("primality: the factors do not divide %d", m)
+	}
+	if c.D != nil {
+		f.Sub(f, Big1)
+	}
+	if f.Mul(f, f).Cmp(n) <= 0 {
+		return fmt.Errorf("primality: the factored part of %d is too small", m)
+	}
+	return nil
+}
+
+// MarshalText encodes the certificate as lines "N n" and, for the factors of N+1, "D d"
+// followed by lines "Q q e a" for every factor. The certificates of the factors come first,
+// separated by empty lines.
+func (c *PrimeCertificate) MarshalText() ([]byte, error) {
+	var b bytes.Buffer
+	written := make(map[string]bool)
+	var write func(c *PrimeCertificate)
+	write = func(c *PrimeCertificate) {
+		if written[c.N.String()] {
+			return
+		}
+		written[c.N.String()] = true
+		for _, pf := range c.Factors {
+			if pf.Certificate != nil {
+				write(pf.Certificate)
+			}
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "N %d\n", c.N)
+		if c.D != nil {
+			fmt.Fprintf(&b, "D %d\n", c.D)
+		}
+		for _, pf := range c.Factors {
+			fmt.Fprintf(&b, "Q %d %d %d\n", pf.Prime, pf.Exp, pf.Witness)
+		}
+	}
+	write(c)
+	return b.Bytes(), nil
+}
+
+// UnmarshalText decodes the certificate encoded by MarshalText. The certificate must
+// be verified with Verify.
+func (c *PrimeCertificate) UnmarshalText(text []byte) error {
+	certs := make(map[string]*PrimeCertificate)
+	var cur *PrimeCertificate
+	s := bufio.NewScanner(bytes.NewReader(text))
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var nums []*big.Int
+		for _, f := range fields[1:] {
+			x, ok := new(big.Int).SetString(f, 10)
+			if !ok {
+				return fmt.Errorf("primality: line %d: invalid number %q", line, f)
+			}
+			nums = append(nums, x)
+		}
+		switch {
+		case fields[0] == "N" && len(nums) == 1:
+			cur = &PrimeCertificate{N: nums[0]}
+			certs[cur.N.String()] = cur
+		case fields[0] == "D" && len(nums) == 1 && cur != nil && cur.D == nil:
+			cur.D = nums[0]
+		case fields[0] == "Q" && len(nums) == 3 && cur != nil:
+			if nums[0].Cmp(cur.N) >= 0 {
+				return fmt.Errorf("primality: line %d: the factor is not less than N", line)
+			}
+			if !nums[1].IsInt64() || nums[1].Int64() < 1 || nums[1].Int64() > 1<<16 {
+				return fmt.Errorf("primality: line %d: invalid exponent", line)
+			}
+			cur.Factors = append(cur.Factors, PocklingtonFactor{
+				Prime:       nums[0],
+				Exp:         int(nums[1].Int64()),
+				Witness:     nums[2],
+				Certificate: certs[nums[0].String()],
+			})
+		default:
+			return fmt.Errorf("primality: line %d: syntax error", line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if cur == nil {
+		return errors.New("primality: empty certificate")
+	}
+	*c = *cur
+	return nil
+}
+
+// ProvePrime returns a certificate for the prime n. The factors of n-1 and n+1 are found
+// with factorizeWith, and the unfactored parts are factored again with more effort for larger
+// factors, so the proof fails if less than a half of both n-1 and n+1 is factored before ctx is done.
+func ProvePrime(ctx context.Context, n *big.Int) (*PrimeCertificate, error) {
+	if !BPSW(n) {
+		return nil, fmt.Errorf("primality: %d is composite", n)
+	}
+	if n.Cmp(bpswBound) < 0 {
+		return &PrimeCertificate{N: new(big.Int).Set(n)}, nil
+	}
+
+	proofs := []*primeProof{newPrimeProof(n, nil), newPrimeProof(n, lucasDiscriminant(n))}
+	for bits := 48; bits <= 80 && ctx.Err() == nil; bits += 16 {
+		// The proof with the smaller unfactored part is more likely to be completed first.
+		if proofs[1].rest.Cmp(proofs[0].rest) < 0 {
+			proofs[0], proofs[1] = proofs[1], proofs[0]
+		}
+		for _, pr := range proofs {
+			ok, err := pr.extend(ctx, bits)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return pr.c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("primality: failed to factor a half of %d-1 or %d+1", n, n)
+}
+
+// primeProof is a certificate being built from the factors of n-1, or of n+1 if d is set.
+type primeProof struct {
+	c     *PrimeCertificate
+	f     *big.Int
+	rest  *big.Int
+	index map[string]int
+}
+
+func newPrimeProof(n, d *big.Int) *primeProof {
+	rest := new(big.Int).Sub(n, Big1)
+	if d != nil {
+		rest.Add(n, Big1)
+	}
+	return &primeProof{
+		c:     &PrimeCertificate{N: new(big.Int).Set(n), D: d},
+		f:     big.NewInt(1),
+		rest:  rest,
+		index: make(map[string]int),
+	}
+}
+
+// extend factors the rest of n-1 (or n+1) with the effort for the factors of the given size
+// and adds the proven factors to the certificate. It reports whether the certificate is complete.
+func (pr *primeProof) extend(ctx context.Context, bits int) (bool, error) {
+	n := pr.c.N
+	if pr.rest.Cmp(Big1) == 0 {
+		return false, nil
+	}
+	fact, err := factorizeWith(ctx, pr.rest, factorConfig{FactorBits: bits})
+	if err != nil {
+		return false, err
+	}
+	pr.rest = fact.Remainder
+	for _, pf := range append(fact.Factors, fact.Probable...) {
+		if i, ok := pr.index[pf.fact.String()]; ok {
+			pr.c.Factors[i].Exp += int(pf.exp)
+			pr.f.Mul(pr.f, new(big.Int).Exp(pf.fact, big.NewInt(pf.exp), nil))
+			continue
+		}
+		var qc *PrimeCertificate
+		if pf.fact.Cmp(bpswBound) >= 0 {
+			if qc, err = ProvePrime(ctx, pf.fact); err != nil {
+				continue
+			}
+		}
+		var a *big.Int
+		if pr.c.D != nil {
+			a, err = lucasWitness(n, pr.c.D, pf.fact)
+		} else {
+			a, err = pocklingtonWitness(n, pf.fact)
+		}
+		if err != nil {
+			return false, err
+		}
+		pr.index[pf.fact.String()] = len(pr.c.Factors)
+		pr.c.Factors = append(pr.c.Factors, PocklingtonFactor{pf.fact, int(pf.exp), a, qc})
+		pr.f.Mul(pr.f, new(big.Int).Exp(pf.fact, big.NewInt(pf.exp), nil))
+	}
+
+	f := new(big.Int).Set(pr.f)
+	if pr.c.D != nil {
+		f.Sub(f, Big1)
+	}
+	return f.Mul(f, f).Cmp(n) > 0, nil
+}
+
+// pocklingtonWitness returns a witness for the prime factor q of n-1.
+// For a prime n, all but 1/q of the bases are witnesses.
+func pocklingtonWitness(n, q *big.Int) (*big.Int, error) {
+	nm1 := new(big.Int).Sub(n, Big1)
+	e := new(big.Int).Div(nm1, q)
+	for a := int64(2); a < 1000; a++ {
+		ab := big.NewInt(a)
+		if new(big.Int).Exp(ab, nm1, n).Cmp(Big1) != 0 {
+			return nil, fmt.Errorf("primality: %d is composite", n)
+		}
+		t := new(big.Int).Exp(ab, e, n)
+		if t.Sub(t, Big1).GCD(nil, nil, t, n).Cmp(Big1) == 0 {
+			return ab, nil
+		}
+	}
+	return nil, fmt.Errorf("primality: no witness for %d", q)
+}
+
+// lucasDiscriminant returns the first D of 5, -7, 9, -11, ... with (D/n) = -1 for the odd n
+// that is not a square.
+func lucasDiscriminant(n *big.Int) *big.Int {
+	d := big.NewInt(5)
+	for big.Jacobi(d, n) != -1 {
+		if d.Sign() > 0 {
+			d.Neg(d).Sub(d, Big2)
+		} else {
+			d.Neg(d).Add(d, Big2)
+		}
+	}
+	return d
+}
+
+// lucasWitness returns a witness P for the prime factor q of n+1. P is odd, since D = 1 mod 4.
+// For a prime n, all but about 1/q of the sequences are witnesses.
+func lucasWitness(n, d, q *big.Int) (*big.Int, error) {
+	for p := int64(1); p < 2000; p += 2 {
+		a := big.NewInt(p)
+		if err := checkLucasWitness(n, d, q, a); err == nil {
+			return a, nil
+		} else if err == errLucasComposite {
+			return nil, fmt.Errorf("primality: %d is composite", n)
+		}
+	}
+	return nil, fmt.Errorf("primality: no witness for %d", q)
+}
+
+var errLucasComposite = errors.New("primality: U_(N+1) != 0 mod N")
+
+// checkLucasWitness checks that the Lucas sequence with P = a and Q = (a^2 - d)/4 satisfies
+// U_(n+1) = 0 mod n and gcd(U_((n+1)/q), n) = 1.
+func checkLucasWitness(n, d, q, a *big.Int) error {
+	lq := new(big.Int).Mul(a, a)
+	lq.Sub(lq, d)
+	if lq.Bit(0) != 0 || lq.Bit(1) != 0 {
+		return fmt.Errorf("primality: invalid witness for %d", q)
+	}
+	lq.Rsh(lq, 2)
+	if lq.Sign() == 0 || new(big.Int).GCD(nil, nil, new(big.Int).Abs(lq), n).Cmp(Big1) != 0 {
+		return fmt.Errorf("primality: invalid witness for %d", q)
+	}
+
+	np1 := new(big.Int).Add(n, Big1)
+	if lucasU(a, lq, d, np1, n).Sign() != 0 {
+		return errLucasComposite
+	}
+	u := lucasU(a, lq, d, new(big.Int).Div(np1, q), n)
+	if u.GCD(nil, nil, u, n).Cmp(Big1) != 0 {
+		return fmt.Errorf("primality: invalid witness for %d", q)
+	}
+	return nil
+}
+
+// lucasU returns U_k mod n of the Lucas sequence with the parameters P and Q, P^2 - 4Q = D,
+// for the odd n and k >= 1.
+func lucasU(P, Q, D, k, n *big.Int) *big.Int {
+	// half returns x/2 mod n.
+	half := func(x *big.Int) {
+		if x.Bit(0) == 1 {
+			x.Add(x, n)
+		}
+		x.Rsh(x, 1)
+	}
+	// U_1 = 1, V_1 = P. Doubling: U_2j = U_j*V_j, V_2j = V_j^2 - 2Q^j;
+	// increment: U_(j+1) = (P*U_j + V_j)/2, V_(j+1) = (D*U_j + P*V_j)/2.
+	u := big.NewInt(1)
+	v := new(big.Int).Mod(P, n)
+	qk := new(big.Int).Mod(Q, n)
+	t := new(big.Int)
+	for i := k.BitLen() - 2; i >= 0; i-- {
+		u.Mul(u, v)
+		u.Mod(u, n)
+		v.Mul(v, v)
+		v.Sub(v, t.Lsh(qk, 1))
+		v.Mod(v, n)
+		qk.Mul(qk, qk)
+		qk.Mod(qk, n)
+		if k.Bit(i) == 1 {
+			t.Mul(D, u)
+			u.Mul(u, P)
+			u.Add(u, v)
+			u.Mod(u, n)
+			v.Mul(v, P)
+			v.Add(v, t)
+			v.Mod(v, n)
+			half(u)
+			half(v)
+			qk.Mul(qk, Q)
+			qk.Mod(qk, n)
+		}
+	}
+	return u.Mod(u, n)
+}
+
+// ErrSafePrimeOrder is returned by CertifyGroupOrder for the groups with a safe prime
+// P = 2Q+1 and Q of more than 256 bits, such as the RFC 3526, RFC 7919 and RFC 5054 groups.
+// Q-1 and Q+1 of these groups are random numbers of the size of P, so neither has a known
+// factored half, and proving Q needs a method like ECPP that is not implemented here.
+// The orders of these groups are not certified; they only pass the probabilistic tests.
+var ErrSafePrimeOrder = errors.New("primality: proving the order of a large safe-prime group is not supported")
+
+// CertifyGroupOrder proves that the order Q of the subgroup of the group is prime.
+// The proof uses the factors of Q-1 or Q+1, so it works for the groups with a Q much
+// smaller than P, like the RFC 5114 groups, and fails with ErrSafePrimeOrder for the
+// large safe-prime groups, which are most of the built-in groups.
+func CertifyGroupOrder(ctx context.Context, group *dhgroup.GroupParams) (*PrimeCertificate, error) {
+	if group.Q == nil {
+		return nil, errors.New("primality: the group has no order")
+	}
+	p := new(big.Int).Lsh(group.Q, 1)
+	if group.Q.BitLen() > 256 && p.Add(p, Big1).Cmp(group.P) == 0 {
+		return nil, ErrSafePrimeOrder
+	}
+	return ProvePrime(ctx, group.Q)
+}
+
+// CertifyCurveOrder proves that the order N of the base point of the curve is prime.
+func CertifyCurveOrder(ctx context.Context, curve elliptic.Curve) (*PrimeCertificate, error) {
+	n := curve.Params().N
+	if n == nil {
+		return nil, errors.New("primality: the curve has no order")
+	}
+	return ProvePrime(ctx, n)
+}
+
+// GeneratePrime returns a random prime of the given size with a certificate.
+// It uses Maurer's method: a prime q of about half the size is generated recursively,
+// and p = 2kq+1 is tried for random k, so that q > sqrt(p) proves p.
+func GeneratePrime(rng io.Reader, bits int) (*big.Int, *PrimeCertificate, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	if bits < 2 {
+		return nil, nil, errors.New("primality: primes must be at least 2 bits long")
+	}
+	if bits <= 64 {
+		for {
+			p, err := rand.Int(rng, new(big.Int).Lsh(Big1, uint(bits-1)))
+			if err != nil {
+				return nil, nil, err
+			}
+			p.SetBit(p, bits-1, 1)
+			if bits > 2 {
+				p.SetBit(p, 0, 1)
+			}
+			if BPSW(p) {
+				return p, &PrimeCertificate{N: p}, nil
+			}
+		}
+	}
+
+	// q^2 >= 2^bits > p.
+	q, qc, err := GeneratePrime(rng, (bits+1)/2+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return primeWithFactors(rng, bits, []*PrimeCertificate{qc}, q)
+}
+
+// GeneratePrimeWithFactor returns a prime p = k*q+1 of the given size with a certificate
+// for the certified prime q. If q < sqrt(p), k has a generated prime factor r such that
+// q*r > sqrt(p) proves p.
+func GeneratePrimeWithFactor(rng io.Reader, bits int, q *PrimeCertificate) (*big.Int, *PrimeCertificate, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	if err := q.Verify(); err != nil {
+		return nil, nil, err
+	}
+	if q.N.BitLen()+2 > bits {
+		return nil, nil, errors.New("primality: the factor is too large")
+	}
+	certs := []*PrimeCertificate{q}
+	m := new(big.Int).Set(q.N)
+	if need := (bits+1)/2 + 1 - (q.N.BitLen() - 1); need > 1 {
+		if q.N.BitLen()+need+1 > bits {
+			return nil, nil, errors.New("primality: the factor is too large")
+		}
+		r, rc, err := GeneratePrime(rng, need)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, rc)
+		m.Mul(m, r)
+	}
+	return primeWithFactors(rng, bits, certs, m)
+}
+
+// GenerateSafePrime returns a safe prime p = 2q+1 of the given size with a certificate.
+// The prime q is generated as in GeneratePrime, and the certificate of p has the
+// factors 2 and q.
+func GenerateSafePrime(rng io.Reader, bits int) (*big.Int, *PrimeCertificate, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	if bits < 66 {
+		return nil, nil, errors.New("primality: safe primes must be at least 66 bits long")
+	}
+	// r^2 > q, where q has bits-1 bits.
+	r, rc, err := GeneratePrime(rng, bits/2+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		q, qc, err := primeWithFactors(rng, bits-1, []*PrimeCertificate{rc}, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		p := new(big.Int).Lsh(q, 1)
+		p.Add(p, Big1)
+		if hasSmallFactor(p) || !BPSW(p) {
+			continue
+		}
+		a2, err := pocklingtonWitness(p, Big2)
+		if err != nil {
+			return nil, nil, err
+		}
+		aq, err := pocklingtonWitness(p, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, &PrimeCertificate{N: p, Factors: []PocklingtonFactor{
+			{Prime: big.NewInt(2), Exp: 1, Witness: a2},
+			{Prime: q, Exp: 1, Witness: aq, Certificate: qc},
+		}}, nil
+	}
+}
+
+// primeWithFactors finds a prime p = 2*k*m + 1 of the given size for random k, where m is
+// the product of the certified primes with the certificates certs and m^2 > p.
+func primeWithFactors(rng io.Reader, bits int, certs []*PrimeCertificate, m *big.Int) (*big.Int, *PrimeCertificate, error) {
+	// k is in [2^(bits-1)/2m, 2^bits/2m).
+	m2 := new(big.Int).Lsh(m, 1)
+	kmin := new(big.Int).Lsh(Big1, uint(bits-1))
+	kmin.Add(kmin, m2)
+	kmin.Sub(kmin, Big1)
+	kmin.Div(kmin, m2)
+	kmax := new(big.Int).Lsh(Big1, uint(bits))
+	kmax.Div(kmax, m2)
+	width := new(big.Int).Sub(kmax, kmin)
+	if width.Sign() <= 0 {
+		return nil, nil, errors.New("primality: the factors are too large")
+	}
+
+	for {
+		k, err := rand.Int(rng, width)
+		if err != nil {
+			return nil, nil, err
+		}
+		k.Add(k, kmin)
+		p := k.Mul(k, m2)
+		p.Add(p, Big1)
+		if hasSmallFactor(p) || !BPSW(p) {
+			continue
+		}
+		c := &PrimeCertificate{N: p}
+		for _, qc := range certs {
+			a, err := pocklingtonWitness(p, qc.N)
+			if err != nil {
+				// p is composite.
+				break
+			}
+			pf := PocklingtonFactor{Prime: qc.N, Exp: 1, Witness: a}
+			if qc.N.Cmp(bpswBound) >= 0 {
+				pf.Certificate = qc
+			}
+			c.Factors = append(c.Factors, pf)
+		}
+		if len(c.Factors) == len(certs) {
+			return p, c, nil
+		}
+	}
+}
+
+// smallPrimesProduct is the product of the odd primes below 1000.
+var smallPrimesProduct = func() *big.Int {
+	m := big.NewInt(1)
+	for _, p := range primesBelow(1000)[1:] {
+		m.Mul(m, new(big.Int).SetUint64(p))
+	}
+	return m
+}()
+
+// hasSmallFactor reports whether the odd n > 1000 is divisible by a prime below 1000.
+func hasSmallFactor(n *big.Int) bool {
+	return new(big.Int).GCD(nil, nil, n, smallPrimesProduct).Cmp(Big1) != 0
+}
@@ -0,0 +1,239 @@
+package dhpals
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+	"github.com/dnkolegov/dhpals/elliptic"
+)
+
+type pseudoprimeTest struct {
+	n            string
+	strong, slpp bool
+}
+
+var pseudoprimeTests = []pseudoprimeTest{
+	// Strong pseudoprimes to base 2.
+	{"2047", true, false},
+	{"3277", true, false},
+	{"4033", true, false},
+	{"4681", true, false},
+	{"8321", true, false},
+	{"65281", true, false},
+	// Strong pseudoprime to all prime bases up to 37.
+	{"318665857834031151167461", true, false},
+	// Strong Lucas pseudoprimes.
+	{"5459", false, true},
+	{"5777", false, true},
+	{"10877", false, true},
+	{"16109", false, true},
+	{"18971", false, true},
+	// Carmichael numbers.
+	{"561", false, false},
+	{"1105", false, false},
+	{"1729", false, false},
+}
+
+func TestBPSW(t *testing.T) {
+	for i := int64(0); i < 20000; i++ {
+		n := big.NewInt(i)
+		if BPSW(n) != n.ProbablyPrime(20) {
+			t.Fatalf("%s: BPSW(%d) = %t", t.Name(), n, BPSW(n))
+		}
+	}
+	for _, r := range pseudoprimeTests {
+		n, _ := new(big.Int).SetString(r.n, 10)
+		if strongProbablePrime(n, Big2) != r.strong || strongLucasProbablePrime(n) != r.slpp || BPSW(n) {
+			t.Errorf("%s: wrong result for %d", t.Name(), n)
+		}
+	}
+
+	p := elliptic.P256().Params().N
+	if !BPSW(p) || BPSW(new(big.Int).Mul(p, elliptic.P224().Params().N)) {
+		t.Errorf("%s: wrong result for large numbers", t.Name())
+	}
+}
+
+func TestProvePrime(t *testing.T) {
+	ctx := context.Background()
+	for _, curve := range []elliptic.Curve{elliptic.P128(), elliptic.P224(), elliptic.P256()} {
+		c, err := CertifyCurveOrder(ctx, curve)
+		if err != nil {
+			t.Fatalf("%s: %s: %v", t.Name(), curve.Params().Name, err)
+		}
+		if err := c.Verify(); err != nil {
+			t.Errorf("%s: %s: %v", t.Name(), curve.Params().Name, err)
+		}
+	}
+	// The curve order of the malicious curve is composite.
+	if _, err := CertifyCurveOrder(ctx, elliptic.P128V1()); err == nil {
+		t.Errorf("%s: a certificate for a composite number", t.Name())
+	}
+
+	c, err := CertifyGroupOrder(ctx, dhgroup.MODP1024S160().DHParams())
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	text, _ := c.MarshalText()
+	var c1 PrimeCertificate
+	if err := c1.UnmarshalText(text); err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if err := c1.Verify(); err != nil || c1.N.Cmp(c.N) != 0 {
+		t.Fatalf("%s: the decoded certificate is invalid: %v\n%s", t.Name(), err, text)
+	}
+
+	// The witness for 2 can not be 1, and a half of n-1 must be factored.
+	c1.Factors[0].Witness = big.NewInt(1)
+	if err := c1.Verify(); err == nil {
+		t.Errorf("%s: a certificate with an invalid witness", t.Name())
+	}
+	if err := c1.UnmarshalText(text); err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	c1.Factors = c1.Factors[:len(c1.Factors)-1]
+	if err := c1.Verify(); err == nil {
+		t.Errorf("%s: a certificate with too few factors", t.Name())
+	}
+}
+
+func TestCertifyGroupOrder(t *testing.T) {
+	ctx := context.Background()
+	for _, r := range []struct {
+		id     dhgroup.ID
+		proved bool
+	}{
+		{dhgroup.ModP512v57, true},
+		{dhgroup.ModP512v58, true},
+		{dhgroup.ModP768, false},
+		{dhgroup.ModP1536, false},
+		{dhgroup.ModP2048, false},
+		{dhgroup.ModP1024s160, true},
+		// The orders of the 2048-bit RFC 5114 groups are proved with the factors of Q+1.
+		{dhgroup.ModP2048s224, true},
+		{dhgroup.ModP2048s256, true},
+		{dhgroup.Ffdhe2048, false},
+		{dhgroup.Ffdhe3072, false},
+		{dhgroup.Ffdhe4096, false},
+		{dhgroup.Ffdhe6144, false},
+		{dhgroup.Ffdhe8192, false},
+		{dhgroup.Srp1024, false},
+		{dhgroup.Srp1536, false},
+		{dhgroup.Srp2048, false},
+		{dhgroup.Srp3072, false},
+		{dhgroup.Srp4096, false},
+		{dhgroup.Srp6144, false},
+		{dhgroup.Srp8192, false},
+	} {
+		group, err := dhgroup.GroupForGroupID(r.id)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		params := group.DHParams()
+		c, err := CertifyGroupOrder(ctx, params)
+		if !r.proved {
+			if err != ErrSafePrimeOrder {
+				t.Errorf("%s: %s: got %v, want %v", t.Name(), params.Name, err, ErrSafePrimeOrder)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s: %v", t.Name(), params.Name, err)
+		}
+		text, _ := c.MarshalText()
+		var c1 PrimeCertificate
+		if err := c1.UnmarshalText(text); err != nil {
+			t.Fatalf("%s: %s: %v", t.Name(), params.Name, err)
+		}
+		if err := c1.Verify(); err != nil || c1.N.Cmp(params.Q) != 0 {
+			t.Errorf("%s: %s: the decoded certificate is invalid: %v", t.Name(), params.Name, err)
+		}
+		if c1.D == nil {
+			continue
+		}
+
+		// The factors of Q+1 are not a proof with the Fermat witnesses of Q-1,
+		// and D must be a non-residue.
+		d := c1.D
+		c1.D = nil
+		if err := c1.Verify(); err == nil {
+			t.Errorf("%s: %s: a certificate without the discriminant", t.Name(), params.Name)
+		}
+		c1.D = new(big.Int).Mul(d, d)
+		if err := c1.Verify(); err == nil {
+			t.Errorf("%s: %s: a certificate with a square discriminant", t.Name(), params.Name)
+		}
+	}
+}
+
+func TestCertificateSelfReference(t *testing.T) {
+	// A 71-bit N that lists itself as a factor of N-1.
+	n, _ := new(big.Int).SetString("1180591620717411303449", 10)
+	text := []byte(fmt.Sprintf("N %d\nQ %d 1 2\n", n, n))
+	var c PrimeCertificate
+	if err := c.UnmarshalText(text); err == nil {
+		t.Errorf("%s: a certificate that refers to itself was decoded", t.Name())
+	}
+
+	c = PrimeCertificate{N: n}
+	c.Factors = []PocklingtonFactor{{Prime: n, Exp: 1, Witness: Big2, Certificate: &c}}
+	if err := c.Verify(); err == nil {
+		t.Errorf("%s: a certificate that refers to itself was verified", t.Name())
+	}
+}
+
+func TestGeneratePrime(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(25))
+	for _, bits := range []int{2, 40, 64, 65, 100, 256} {
+		p, c, err := GeneratePrime(rng, bits)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if p.BitLen() != bits || c.N.Cmp(p) != 0 {
+			t.Fatalf("%s: got %d, want a %d-bit prime", t.Name(), p, bits)
+		}
+		if err := c.Verify(); err != nil {
+			t.Errorf("%s: %d bits: %v", t.Name(), bits, err)
+		}
+	}
+}
+
+func TestGenerateSafePrime(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(25))
+	p, c, err := GenerateSafePrime(rng, 256)
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	q := new(big.Int).Rsh(p, 1)
+	if p.BitLen() != 256 || !q.ProbablyPrime(20) {
+		t.Fatalf("%s: %d is not a 256-bit safe prime", t.Name(), p)
+	}
+	if err := c.Verify(); err != nil {
+		t.Errorf("%s: %v", t.Name(), err)
+	}
+}
+
+func TestGeneratePrimeWithFactor(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(25))
+	// A small q needs another factor of p-1 in the certificate, a large q is enough.
+	for _, qbits := range []int{40, 160, 300} {
+		q, qc, err := GeneratePrime(rng, qbits)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		p, c, err := GeneratePrimeWithFactor(rng, 512, qc)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if p.BitLen() != 512 || !divides(q, new(big.Int).Sub(p, Big1)) {
+			t.Fatalf("%s: %d is not a 512-bit prime of the form k*%d+1", t.Name(), p, q)
+		}
+		if err := c.Verify(); err != nil {
+			t.Errorf("%s: %d-bit q: %v", t.Name(), qbits, err)
+		}
+	}
+}
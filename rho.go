@@ -0,0 +1,202 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// rhoCycle selects the cycle detection method of Pollard's rho.
+type rhoCycle int
+
+const (
+	// rhoFloyd compares x_i and x_2i. It needs three group operations per step.
+	rhoFloyd rhoCycle = iota
+	// rhoBrent compares x_i with the saved x_(2^k), where 2^k <= i < 2^(k+1).
+	// It needs one group operation per step, but finds the collision a bit later.
+	rhoBrent
+)
+
+// rhoPartitions is the number of partitions of the r-adding walk.
+// Teske showed that r = 20 makes the walk behave like a random one.
+const rhoPartitions = 20
+
+// rhoMaxAttempts is the number of walks pollardRho tries before giving up.
+const rhoMaxAttempts = 10
+
+// rhoMaxDegree limits the number of candidate solutions checked when
+// gcd(b1 - b2, n) = d > 1 for a composite order n.
+const rhoMaxDegree = 1 << 16
+
+var errRhoDegenerate = errors.New("rho: degenerate collision")
+
+// rhoPoint is a group element x = g^a * y^b.
+type rhoPoint struct {
+	x, a, b *big.Int
+}
+
+// rhoWalk is an r-adding walk: x -> x * M_i for i = h(x), where M_i = g^(a_i) * y^(b_i)
+// are random multipliers and h partitions the group into r sets.
+type rhoWalk struct {
+	p, n   *big.Int
+	m      []rhoPoint
+	xa, xb *big.Int
+}
+
+func newRhoWalk(p, g, y, n *big.Int) (*rhoWalk, error) {
+	w := &rhoWalk{p: p, n: n, m: make([]rhoPoint, rhoPartitions)}
+	for i := range w.m {
+		pt, err := randomRhoPoint(p, g, y, n)
+		if err != nil {
+			return nil, err
+		}
+		w.m[i] = pt
+	}
+	return w, nil
+}
+
+func randomRhoPoint(p, g, y, n *big.Int) (rhoPoint, error) {
+	a, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		return rhoPoint{}, err
+	}
+	b, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		return rhoPoint{}, err
+	}
+	x := new(big.Int).Exp(g, a, p)
+	x.Mul(x, new(big.Int).Exp(y, b, p))
+	x.Mod(x, p)
+	return rhoPoint{x: x, a: a, b: b}, nil
+}
+
+// step moves the point to the next one in place.
+func (w *rhoWalk) step(pt *rhoPoint) {
+	var h uint
+	if words := pt.x.Bits(); len(words) > 0 {
+		h = uint(words[0]) % rhoPartitions
+	}
+	m := &w.m[h]
+	pt.x.Mul(pt.x, m.x)
+	pt.x.Mod(pt.x, w.p)
+	pt.a.Add(pt.a, m.a)
+	if pt.a.Cmp(w.n) >= 0 {
+		pt.a.Sub(pt.a, w.n)
+	}
+	pt.b.Add(pt.b, m.b)
+	if pt.b.Cmp(w.n) >= 0 {
+		pt.b.Sub(pt.b, w.n)
+	}
+}
+
+func (pt rhoPoint) clone() rhoPoint {
+	return rhoPoint{x: new(big.Int).Set(pt.x), a: new(big.Int).Set(pt.a), b: new(big.Int).Set(pt.b)}
+}
+
+// pollardRho finds x such that g^x = y mod p, where g has order n, using Pollard's rho
+// with an r-adding walk. A degenerate collision (the same exponents of y) gives
+// no information, so a new walk with new multipliers and a new starting point is tried.
+func pollardRho(p, g, y, n *big.Int, cycle rhoCycle) (*big.Int, error) {
+	if new(big.Int).Exp(y, n, p).Cmp(Big1) != 0 {
+		return nil, errors.New("rho: y is not in the subgroup generated by g")
+	}
+
+	var err error
+	for attempt := 0; attempt < rhoMaxAttempts; attempt++ {
+		var x *big.Int
+		x, err = rhoAttempt(p, g, y, n, cycle)
+		if err == nil {
+			return x, nil
+		}
+		if err != errRhoDegenerate {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// rhoSubgroup solves y = G^x in the subgroup of order Q of the group.
+func rhoSubgroup(group *dhgroup.GroupParams, y *big.Int, cycle rhoCycle) (*big.Int, error) {
+	return pollardRho(group.P, group.G, y, group.Q, cycle)
+}
+
+// rhoAttempt runs a single walk until a collision.
+func rhoAttempt(p, g, y, n *big.Int, cycle rhoCycle) (*big.Int, error) {
+	w, err := newRhoWalk(p, g, y, n)
+	if err != nil {
+		return nil, err
+	}
+	start, err := randomRhoPoint(p, g, y, n)
+	if err != nil {
+		return nil, err
+	}
+
+	var t, h rhoPoint
+	switch cycle {
+	case rhoFloyd:
+		t, h = start, start.clone()
+		for {
+			w.step(&t)
+			w.step(&h)
+			w.step(&h)
+			if t.x.Cmp(h.x) == 0 {
+				break
+			}
+		}
+	case rhoBrent:
+		t, h = start, start.clone()
+		w.step(&h)
+		for power, lam := 1, 1; t.x.Cmp(h.x) != 0; lam++ {
+			if power == lam {
+				t = h.clone()
+				power *= 2
+				lam = 0
+			}
+			w.step(&h)
+		}
+	default:
+		return nil, errors.New("rho: unknown cycle detection method")
+	}
+
+	return rhoSolveCollision(p, g, y, n, t, h)
+}
+
+// rhoSolveCollision solves g^a1 * y^b1 = g^a2 * y^b2, i.e. (b1 - b2)*x = a2 - a1 mod n.
+// If d = gcd(b1 - b2, n) > 1, there are d candidate solutions and the right one is
+// found by checking g^x = y.
+func rhoSolveCollision(p, g, y, n *big.Int, c1, c2 rhoPoint) (*big.Int, error) {
+	db := new(big.Int).Sub(c1.b, c2.b)
+	db.Mod(db, n)
+	if db.Sign() == 0 {
+		return nil, errRhoDegenerate
+	}
+	da := new(big.Int).Sub(c2.a, c1.a)
+	da.Mod(da, n)
+
+	d := new(big.Int).GCD(nil, nil, db, n)
+	if !divides(d, da) {
+		return nil, errors.New("rho: inconsistent collision")
+	}
+	if d.Cmp(big.NewInt(rhoMaxDegree)) > 0 {
+		return nil, errRhoDegenerate
+	}
+
+	// x = x0 + k*n/d for k = 0..d-1, where x0 = (da/d) * (db/d)^-1 mod n/d.
+	nd := new(big.Int).Div(n, d)
+	x := new(big.Int).ModInverse(new(big.Int).Div(db, d), nd)
+	x.Mul(x, new(big.Int).Div(da, d))
+	x.Mod(x, nd)
+	gx := new(big.Int).Exp(g, x, p)
+	gnd := new(big.Int).Exp(g, nd, p)
+	for k := int64(0); k < d.Int64(); k++ {
+		if gx.Cmp(y) == 0 {
+			return x, nil
+		}
+		x.Add(x, nd)
+		gx.Mul(gx, gnd)
+		gx.Mod(gx, p)
+	}
+	return nil, errors.New("rho: no solution")
+}
@@ -0,0 +1,88 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestPollardRho(t *testing.T) {
+	group, _, err := dhgroup.GenerateWeakGroup(mrand.New(mrand.NewSource(18)),
+		dhgroup.WeakGroupConfig{QBits: 36, SmoothnessBound: 1 << 16, SmallFactors: 4})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+
+	for _, cycle := range []rhoCycle{rhoFloyd, rhoBrent} {
+		for i := 0; i < 3; i++ {
+			x, _ := rand.Int(rand.Reader, group.Q)
+			y := group.ScalarBaseExp(x)
+
+			xx, err := rhoSubgroup(group, y, cycle)
+			if err != nil {
+				t.Fatalf("%s: cycle detection %d: %v", t.Name(), cycle, err)
+			}
+			if xx.Cmp(x) != 0 {
+				t.Fatalf("%s: cycle detection %d: want %d, got %d", t.Name(), cycle, x, xx)
+			}
+		}
+	}
+}
+
+func TestPollardRhoCompositeOrder(t *testing.T) {
+	// 10 generates Z_p^* of order p-1 = 2 * 3 * 5 * 7 * 100169, so gcd(b1 - b2, p-1)
+	// is often greater than 1.
+	p := big.NewInt(21035491)
+	g := big.NewInt(10)
+	n := new(big.Int).Sub(p, Big1)
+
+	for _, cycle := range []rhoCycle{rhoFloyd, rhoBrent} {
+		x, _ := rand.Int(rand.Reader, n)
+		y := new(big.Int).Exp(g, x, p)
+		xx, err := pollardRho(p, g, y, n, cycle)
+		if err != nil {
+			t.Fatalf("%s: %v", t.Name(), err)
+		}
+		if new(big.Int).Exp(g, xx, p).Cmp(y) != 0 {
+			t.Fatalf("%s: g^%d != %d", t.Name(), xx, y)
+		}
+	}
+}
+
+func TestRhoDegenerateCollision(t *testing.T) {
+	p, g, n := big.NewInt(23), big.NewInt(2), big.NewInt(11)
+	y := big.NewInt(8)
+
+	c1 := rhoPoint{x: big.NewInt(4), a: big.NewInt(2), b: big.NewInt(5)}
+	c2 := rhoPoint{x: big.NewInt(4), a: big.NewInt(2), b: big.NewInt(16)}
+	if _, err := rhoSolveCollision(p, g, y, n, c1, c2); err != errRhoDegenerate {
+		t.Errorf("%s: degenerate collision was not detected", t.Name())
+	}
+
+	// 2^1 * 8^0 = 2^9 * 8^1 = 2 mod 23.
+	c2 = rhoPoint{x: big.NewInt(2), a: big.NewInt(9), b: big.NewInt(1)}
+	c1 = rhoPoint{x: big.NewInt(2), a: big.NewInt(1), b: big.NewInt(0)}
+	if x, err := rhoSolveCollision(p, g, y, n, c1, c2); err != nil || x.Int64() != 3 {
+		t.Errorf("%s: got %d, %v, want 3", t.Name(), x, err)
+	}
+}
+
+func BenchmarkRhoFloyd(b *testing.B) {
+	benchmarkRho(b, rhoFloyd)
+}
+
+func BenchmarkRhoBrent(b *testing.B) {
+	benchmarkRho(b, rhoBrent)
+}
+
+func benchmarkRho(b *testing.B, cycle rhoCycle) {
+	group, _, _ := dhgroup.GenerateWeakGroup(mrand.New(mrand.NewSource(18)),
+		dhgroup.WeakGroupConfig{QBits: 32, SmoothnessBound: 1 << 16, SmallFactors: 4})
+	y := group.ScalarBaseExp(big.NewInt(123456789))
+	for n := 0; n < b.N; n++ {
+		_, _ = rhoSubgroup(group, y, cycle)
+	}
+}
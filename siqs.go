@@ -0,0 +1,712 @@
+package dhpals
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// The self-initializing quadratic sieve (SIQS) for composites of up to ~220 bits whose
+// factors are too large for ECM.
+//
+// Let kN be n times a small multiplier k. For a = q_1 ... q_s, a product of factor base
+// primes close to sqrt(2kN)/M, and b with b^2 = kN mod a, the values of
+//   g(x) = ((ax+b)^2 - kN) / a = ax^2 + 2bx + c
+// are about M*sqrt(kN/2) for x in [-M, M), and (ax+b)^2 = a*g(x) mod n. Then
+//  1. sieving: log p is added at the roots of g mod p for every prime p of the factor
+//     base, and g(x) is factored by trial division where the sum is close to log|g(x)|.
+//     A value that is smooth except for one large prime gives a partial relation, and
+//     two partials with the same large prime are combined into a full relation;
+//  2. self-initialization: every a gives 2^(s-1) polynomials with b = ±B_1 ± ... ± B_s,
+//     and the roots for the next b are obtained from the previous ones with one addition
+//     per prime (Gray code);
+//  3. linear algebra: a set of relations whose product is a square X^2 = Y^2 mod n is
+//     found by Gaussian elimination over GF(2), and gcd(X-Y, n) splits n with
+//     probability 1/2 for every such set.
+
+// siqsParams are the parameters of the sieve for kN of up to bits bits.
+type siqsParams struct {
+	bits int
+	// fbSize is the number of primes in the factor base.
+	fbSize int
+	// m is the half-width of the sieve interval.
+	m int
+	// lpMult bounds the large prime of partial relations by lpMult times the largest
+	// prime of the factor base.
+	lpMult uint64
+}
+
+// siqsParamsTable is adapted from msieve with larger factor bases, since sieving
+// is slower than trial division here.
+var siqsParamsTable = []siqsParams{
+	{80, 80, 1 << 14, 30},
+	{100, 150, 1 << 15, 30},
+	{120, 250, 1 << 15, 40},
+	{140, 450, 1 << 15, 50},
+	{160, 1000, 1 << 15, 60},
+	{180, 1800, 1 << 15, 80},
+	{200, 3000, 1 << 15, 100},
+	{220, 4500, 1 << 16, 100},
+	{240, 7000, 1 << 16, 120},
+}
+
+// siqsMinBits is the size of the smallest n factored by the sieve. Smaller n are split by rho.
+const siqsMinBits = 64
+
+// siqsExtraRelations is the number of relations collected beyond the size of the factor
+// base. Every one of them gives a dependency that splits n with probability 1/2.
+const siqsExtraRelations = 32
+
+// siqsSmallPrime is the bound of the primes that are not sieved: they take the most
+// time and add little to the sums. The threshold is lowered instead.
+const siqsSmallPrime = 32
+
+// siqsSlack lowers the threshold for the small primes that are not sieved, the rounding
+// of the logarithms and the values of g(x) below the maximum. Trial division is cheap
+// compared to sieving, so it is generous.
+const siqsSlack = 12
+
+// siqsBlockSize is the size of the blocks of the sieve array. It divides 2M.
+const siqsBlockSize = 1 << 15
+
+// siqsMultipliers are the candidate multipliers of n. They are squarefree.
+var siqsMultipliers = []uint64{1, 2, 3, 5, 6, 7, 10, 11, 13, 14, 15, 17, 19, 21, 22, 23,
+	26, 29, 30, 31, 33, 34, 35, 37, 38, 39, 41, 42, 43, 46, 47, 51, 53, 55, 57, 59, 61}
+
+// factorSIQS splits the composites of up to ~220 bits with the quadratic sieve.
+// The time depends on the size of n only, so bits is ignored.
+var factorSIQS = factorMethod{"SIQS", siqsSplit}
+
+// siqs is the state of the sieve.
+type siqs struct {
+	n, kn  *big.Int
+	params siqsParams
+
+	// The factor base: primes with (kN/p) != -1, round(log2(p)) and sqrt(kN) mod p.
+	// Column i+1 of the matrix is the exponent of primes[i], and column 0 is the sign.
+	primes []uint32
+	logp   []uint8
+	sqrtKN []uint32
+	// firstSieved is the index of the first sieved prime, and the primes from firstLarge
+	// on are larger than the block size.
+	firstSieved, firstLarge int
+	// threshold is the sum of logarithms that makes x a candidate.
+	threshold int
+	// lpBound is the bound of the large primes of partial relations.
+	lpBound uint64
+
+	// The current polynomial.
+	a, b, c *big.Int
+	aIdx    []int
+	inA     []bool
+	bl      []*big.Int
+	signs   []int
+	// bainv[l][i] = 2*B_l/a mod p_i, root1[i] and root2[i] are the roots of g mod p_i
+	// as indices in the sieve array, that is x + M mod p_i.
+	bainv        [][]uint32
+	root1, root2 []uint32
+	next1, next2 []uint32
+	usedA        map[string]bool
+
+	sieve    []uint8
+	rels     []siqsRelation
+	partials map[uint64]siqsRelation
+}
+
+// siqsRelation is u^2 = ∏ p_i mod n for the primes of the factor base in columns cols
+// (repeated according to their exponents).
+type siqsRelation struct {
+	u    *big.Int
+	cols []int
+}
+
+// siqsSplit returns a nontrivial factor of the composite n, which has no small factors
+// and is not a perfect power, or nil if ctx is done first.
+func siqsSplit(ctx context.Context, n *big.Int, _ int) *big.Int {
+	if n.BitLen() < siqsMinBits {
+		return rhoBrentSplit(ctx, n, n.BitLen())
+	}
+	s, d := newSIQS(n)
+	if d != nil {
+		return d
+	}
+
+	want := len(s.primes) + 1 + siqsExtraRelations
+	for len(s.rels) < want {
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.newA()
+		for i := 0; ; i++ {
+			if d := s.sieveInterval(); d != nil {
+				return d
+			}
+			if i == 1<<uint(len(s.aIdx)-1)-1 || len(s.rels) >= want {
+				break
+			}
+			s.nextB(i + 1)
+		}
+	}
+	return s.findFactor()
+}
+
+// newSIQS chooses the multiplier and the factor base. If a prime of the factor base
+// divides n, it is returned instead.
+func newSIQS(n *big.Int) (*siqs, *big.Int) {
+	kn := new(big.Int).Mul(n, new(big.Int).SetUint64(siqsMultiplier(n)))
+	params := siqsParamsTable[len(siqsParamsTable)-1]
+	for _, p := range siqsParamsTable {
+		if kn.BitLen() <= p.bits {
+			params = p
+			break
+		}
+	}
+	s := &siqs{
+		n:        n,
+		kn:       kn,
+		params:   params,
+		sieve:    make([]uint8, 2*params.m),
+		usedA:    make(map[string]bool),
+		partials: make(map[uint64]siqsRelation),
+	}
+
+	// About half of the primes are quadratic residues.
+	bound := uint64(4 * params.fbSize)
+	var primes []uint64
+	for {
+		primes = primesBelow(bound * uint64(math.Log(float64(bound))))
+		if len(primes) >= 2*params.fbSize+100 {
+			break
+		}
+		bound *= 2
+	}
+	pb, r := new(big.Int), new(big.Int)
+	for _, p := range primes {
+		if len(s.primes) == params.fbSize {
+			break
+		}
+		pb.SetUint64(p)
+		r.Mod(kn, pb)
+		var root uint64
+		switch {
+		case p == 2:
+			root = r.Uint64()
+		case r.Sign() == 0:
+			if divides(pb, n) {
+				return nil, new(big.Int).Set(pb)
+			}
+			// p divides k.
+		case big.Jacobi(r, pb) == 1:
+			root = new(big.Int).ModSqrt(r, pb).Uint64()
+		default:
+			continue
+		}
+		if p < siqsSmallPrime {
+			s.firstSieved = len(s.primes) + 1
+		}
+		s.primes = append(s.primes, uint32(p))
+		s.logp = append(s.logp, uint8(math.Log2(float64(p))+0.5))
+		s.sqrtKN = append(s.sqrtKN, uint32(root))
+	}
+	s.inA = make([]bool, len(s.primes))
+	s.root1 = make([]uint32, len(s.primes))
+	s.root2 = make([]uint32, len(s.primes))
+	s.next1 = make([]uint32, len(s.primes))
+	s.next2 = make([]uint32, len(s.primes))
+	s.firstLarge = len(s.primes)
+	for i, p := range s.primes {
+		if p > siqsBlockSize {
+			s.firstLarge = i
+			break
+		}
+	}
+
+	pmax := uint64(s.primes[len(s.primes)-1])
+	s.lpBound = params.lpMult * pmax
+	if s.lpBound > pmax*pmax {
+		s.lpBound = pmax * pmax
+	}
+	// log2 of max|g(x)| = M*sqrt(kN/2), less the large prime and the slack.
+	logMax := math.Log2(float64(params.m)) + float64(kn.BitLen())/2 - 0.5
+	s.threshold = int(logMax - math.Log2(float64(s.lpBound)) - siqsSlack)
+	return s, nil
+}
+
+// siqsMultiplier returns the multiplier k that maximizes the Knuth-Schroeppel function,
+// that is the expected contribution of the small primes to the smoothness of Q(x)
+// less the growth of Q(x) by sqrt(k).
+func siqsMultiplier(n *big.Int) uint64 {
+	small := primesBelow(1000)
+	best, bestScore := uint64(1), math.Inf(-1)
+	kn, pb, r := new(big.Int), new(big.Int), new(big.Int)
+	for _, k := range siqsMultipliers {
+		kn.Mul(n, new(big.Int).SetUint64(k))
+		score := -0.5 * math.Log(float64(k))
+		switch kn.Uint64() % 8 {
+		case 1:
+			score += 2 * math.Ln2
+		case 5:
+			score += math.Ln2
+		default:
+			score += 0.5 * math.Ln2
+		}
+		for _, p := range small[1:] {
+			pb.SetUint64(p)
+			r.Mod(kn, pb)
+			lp := math.Log(float64(p))
+			if r.Sign() == 0 {
+				score += lp / float64(p)
+			} else if big.Jacobi(r, pb) == 1 {
+				score += 2 * lp / float64(p-1)
+			}
+		}
+		if score > bestScore {
+			best, bestScore = k, score
+		}
+	}
+	return best
+}
+
+// newA chooses a new a = q_1 ... q_s close to sqrt(2kN)/M and initializes the
+// first polynomial with b = B_1 + ... + B_s.
+func (s *siqs) newA() {
+	target := new(big.Int).Lsh(s.kn, 1)
+	target.Sqrt(target)
+	target.Div(target, big.NewInt(int64(s.params.m)))
+	logTarget := float64(target.BitLen())
+
+	// The factors of a are chosen among primes of ~11 bits or smaller for small factor bases,
+	// so that there are enough polynomials.
+	qBits := math.Min(11, math.Log2(float64(s.primes[len(s.primes)/2])))
+	k := int(math.Max(2, math.Floor(logTarget/qBits+0.5)))
+	qTarget := math.Exp2(logTarget / float64(k))
+
+	// The pool of primes around qTarget.
+	c := s.firstSieved
+	for c < len(s.primes)-1 && float64(s.primes[c]) < qTarget {
+		c++
+	}
+	lo, hi := c-20, c+20
+	if lo < s.firstSieved {
+		lo = s.firstSieved
+	}
+	if hi > len(s.primes) {
+		hi = len(s.primes)
+	}
+
+	for attempt := 0; ; attempt++ {
+		for i := range s.inA {
+			s.inA[i] = false
+		}
+		a := big.NewInt(1)
+		var idx []int
+		for len(idx) < k-1 {
+			i := lo + randIntn(hi-lo)
+			if !s.inA[i] && s.sqrtKN[i] != 0 {
+				s.inA[i] = true
+				idx = append(idx, i)
+				a.Mul(a, big.NewInt(int64(s.primes[i])))
+			}
+		}
+		// The last prime is the closest to target/a.
+		rest := new(big.Int).Div(target, a).Uint64()
+		last := -1
+		for i := s.firstSieved; i < len(s.primes); i++ {
+			if s.inA[i] || s.sqrtKN[i] == 0 {
+				continue
+			}
+			if last < 0 || absDiff(uint64(s.primes[i]), rest) < absDiff(uint64(s.primes[last]), rest) {
+				last = i
+			}
+		}
+		s.inA[last] = true
+		idx = append(idx, last)
+		a.Mul(a, big.NewInt(int64(s.primes[last])))
+		if s.usedA[a.String()] && attempt < 100 {
+			continue
+		}
+		s.usedA[a.String()] = true
+		s.a, s.aIdx = a, idx
+		break
+	}
+	s.initPolynomial()
+}
+
+// initPolynomial computes B_l, b and the roots of the first polynomial of a.
+func (s *siqs) initPolynomial() {
+	// B_l = a/q_l * (sqrt(kN) * (a/q_l)^-1 mod q_l), so B_l^2 = kN mod q_l and B_l = 0 mod q_j
+	// for j != l.
+	s.bl = s.bl[:0]
+	s.signs = s.signs[:0]
+	s.b = new(big.Int)
+	for _, i := range s.aIdx {
+		q := big.NewInt(int64(s.primes[i]))
+		aq := new(big.Int).Div(s.a, q)
+		g := new(big.Int).ModInverse(new(big.Int).Mod(aq, q), q)
+		g.Mul(g, big.NewInt(int64(s.sqrtKN[i])))
+		g.Mod(g, q)
+		if g.Cmp(new(big.Int).Rsh(q, 1)) > 0 {
+			g.Sub(q, g)
+		}
+		bl := aq.Mul(aq, g)
+		s.bl = append(s.bl, bl)
+		s.signs = append(s.signs, 1)
+		s.b.Add(s.b, bl)
+	}
+	s.updateC()
+
+	if s.bainv == nil {
+		s.bainv = make([][]uint32, 0)
+	}
+	for len(s.bainv) < len(s.aIdx) {
+		s.bainv = append(s.bainv, make([]uint32, len(s.primes)))
+	}
+	m := uint64(s.params.m)
+	pb, r := new(big.Int), new(big.Int)
+	for i := s.firstSieved; i < len(s.primes); i++ {
+		if s.inA[i] {
+			continue
+		}
+		p := uint64(s.primes[i])
+		pb.SetUint64(p)
+		ainv := invMod(r.Mod(s.a, pb).Uint64(), p)
+		for l, bl := range s.bl {
+			s.bainv[l][i] = uint32(2 * r.Mod(bl, pb).Uint64() * ainv % p)
+		}
+		bm := r.Mod(s.b, pb).Uint64()
+		t := uint64(s.sqrtKN[i])
+		s.root1[i] = uint32((ainv*((t+p-bm)%p) + m) % p)
+		s.root2[i] = uint32((ainv*((2*p-t-bm)%p) + m) % p)
+	}
+}
+
+// nextB switches to the i-th polynomial of a by changing the sign of one B_l.
+func (s *siqs) nextB(i int) {
+	l := bits.TrailingZeros(uint(i))
+	// b' = b - 2*sign*B_l, so the roots ainv*(±t - b) grow by sign*2*B_l/a.
+	sign := s.signs[l]
+	s.signs[l] = -sign
+	t := new(big.Int).Lsh(s.bl[l], 1)
+	if sign > 0 {
+		s.b.Sub(s.b, t)
+	} else {
+		s.b.Add(s.b, t)
+	}
+	s.updateC()
+
+	bainv := s.bainv[l]
+	for i := s.firstSieved; i < len(s.primes); i++ {
+		p := s.primes[i]
+		d := bainv[i]
+		if sign < 0 {
+			d = (p - d) % p
+		}
+		s.root1[i] = (s.root1[i] + d) % p
+		s.root2[i] = (s.root2[i] + d) % p
+	}
+}
+
+// updateC computes c = (b^2 - kN)/a.
+func (s *siqs) updateC() {
+	s.c = new(big.Int).Mul(s.b, s.b)
+	s.c.Sub(s.c, s.kn)
+	s.c.Quo(s.c, s.a)
+}
+
+// sieveInterval sieves g(x) for x in [-M, M) and collects the relations.
+// It returns a factor of n if one is found by chance.
+func (s *siqs) sieveInterval() *big.Int {
+	// The candidates have the sum of logarithms at least threshold, so the sieve starts
+	// at 128 - threshold and they are found by the highest bit.
+	init := uint8(128 - s.threshold)
+	sieve := s.sieve
+	for j := range sieve {
+		sieve[j] = init
+	}
+	// The primes below the block size are sieved block by block, so that the block
+	// stays in the L1 cache.
+	size := uint32(len(sieve))
+	next1, next2 := s.next1, s.next2
+	copy(next1, s.root1)
+	copy(next2, s.root2)
+	for end := uint32(siqsBlockSize); end <= size; end += siqsBlockSize {
+		for i := s.firstSieved; i < s.firstLarge; i++ {
+			if s.inA[i] {
+				continue
+			}
+			p, lp := s.primes[i], s.logp[i]
+			j := next1[i]
+			for ; j < end; j += p {
+				sieve[j] += lp
+			}
+			next1[i] = j
+			if j = next2[i]; s.root2[i] != s.root1[i] {
+				for ; j < end; j += p {
+					sieve[j] += lp
+				}
+				next2[i] = j
+			}
+		}
+	}
+	for i := s.firstLarge; i < len(s.primes); i++ {
+		if s.inA[i] {
+			continue
+		}
+		p, lp := s.primes[i], s.logp[i]
+		for j := s.root1[i]; j < size; j += p {
+			sieve[j] += lp
+		}
+		for j := s.root2[i]; j < size; j += p {
+			sieve[j] += lp
+		}
+	}
+
+	const mask = 0x8080808080808080
+	for j := 0; j < len(sieve); j += 8 {
+		if binary.LittleEndian.Uint64(sieve[j:])&mask == 0 {
+			continue
+		}
+		for k := j; k < j+8; k++ {
+			if sieve[k]&0x80 != 0 {
+				if d := s.trialDivide(k); d != nil {
+					return d
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// trialDivide factors g(x) for x = j - M over the factor base and saves the relation.
+func (s *siqs) trialDivide(j int) *big.Int {
+	x := big.NewInt(int64(j - s.params.m))
+	// u = ax + b, u^2 - kN = a*g(x).
+	u := new(big.Int).Mul(s.a, x)
+	u.Add(u, s.b)
+	g := new(big.Int).Mul(u, u)
+	g.Sub(g, s.kn)
+	g.Quo(g, s.a)
+
+	var cols []int
+	if g.Sign() < 0 {
+		cols = append(cols, 0)
+		g.Neg(g)
+	}
+	if g.Sign() == 0 {
+		return nil
+	}
+	for _, i := range s.aIdx {
+		cols = append(cols, i+1)
+	}
+	tz := trailingZeroBits(g)
+	g.Rsh(g, tz)
+	for ; tz > 0; tz-- {
+		cols = append(cols, 1)
+	}
+
+	pb, q, r := new(big.Int), new(big.Int), new(big.Int)
+	var rest uint64
+	small := false
+	for i := 1; i < len(s.primes); i++ {
+		p := uint64(s.primes[i])
+		if !small && g.IsUint64() {
+			rest, small = g.Uint64(), true
+		}
+		// The sieved primes divide g(x) only at the roots.
+		if i >= s.firstSieved && !s.inA[i] {
+			if jm := uint32(j % int(p)); jm != s.root1[i] && jm != s.root2[i] {
+				continue
+			}
+		}
+		if small {
+			for rest%p == 0 {
+				rest /= p
+				cols = append(cols, i+1)
+			}
+			continue
+		}
+		pb.SetUint64(p)
+		for {
+			q.QuoRem(g, pb, r)
+			if r.Sign() != 0 {
+				break
+			}
+			g, q = q, g
+			cols = append(cols, i+1)
+		}
+	}
+	if !small {
+		if !g.IsUint64() {
+			return nil
+		}
+		rest = g.Uint64()
+	}
+
+	u.Mod(u, s.n)
+	switch {
+	case rest == 1:
+		s.rels = append(s.rels, siqsRelation{u, cols})
+	case rest < s.lpBound:
+		// rest is a prime since it is less than the square of the largest prime.
+		p0, ok := s.partials[rest]
+		if !ok {
+			s.partials[rest] = siqsRelation{u, cols}
+			return nil
+		}
+		// (u0*u/L)^2 = Q0*Q/L^2.
+		l := new(big.Int).SetUint64(rest)
+		linv := new(big.Int).ModInverse(l, s.n)
+		if linv == nil {
+			return l
+		}
+		u.Mul(u, p0.u)
+		u.Mul(u, linv)
+		u.Mod(u, s.n)
+		s.rels = append(s.rels, siqsRelation{u, append(append([]int(nil), p0.cols...), cols...)})
+	}
+	return nil
+}
+
+// findFactor finds the dependencies between the relations and tries them one by one.
+func (s *siqs) findFactor() *big.Int {
+	ncols := len(s.primes) + 1
+	words := (ncols + 63) / 64
+	rows := make([][]uint64, len(s.rels))
+	for i, rel := range s.rels {
+		rows[i] = make([]uint64, words)
+		for _, c := range rel.cols {
+			rows[i][c/64] ^= 1 << uint(c%64)
+		}
+	}
+
+	exps := make([]int, ncols)
+	for _, dep := range gf2Dependencies(rows, ncols) {
+		y := big.NewInt(1)
+		for i := range exps {
+			exps[i] = 0
+		}
+		for _, i := range dep {
+			y.Mul(y, s.rels[i].u)
+			y.Mod(y, s.n)
+			for _, c := range s.rels[i].cols {
+				exps[c]++
+			}
+		}
+		// The sign is ignored: X and -X are both square roots.
+		x := big.NewInt(1)
+		for c := 1; c < ncols; c++ {
+			if exps[c] > 0 {
+				pe := new(big.Int).Exp(big.NewInt(int64(s.primes[c-1])), big.NewInt(int64(exps[c]/2)), s.n)
+				x.Mul(x, pe)
+				x.Mod(x, s.n)
+			}
+		}
+		if d := properFactor(x.Sub(y, x), s.n); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// gf2Dependencies returns the sets of rows of a matrix over GF(2) that sum to zero.
+// Every row is a bitset of ncols bits. It uses Gaussian elimination that keeps
+// track of the rows every row is the sum of: the rows that do not become pivots
+// are reduced to zero, and their histories are the dependencies.
+func gf2Dependencies(rows [][]uint64, ncols int) [][]int {
+	hwords := (len(rows) + 63) / 64
+	m := make([][]uint64, len(rows))
+	hist := make([][]uint64, len(rows))
+	for i, row := range rows {
+		m[i] = append([]uint64(nil), row...)
+		hist[i] = make([]uint64, hwords)
+		hist[i][i/64] = 1 << uint(i%64)
+	}
+
+	pivot := make([]bool, len(rows))
+	for c := 0; c < ncols; c++ {
+		w, bit := c/64, uint64(1)<<uint(c%64)
+		p := -1
+		for i := range m {
+			if !pivot[i] && m[i][w]&bit != 0 {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			continue
+		}
+		pivot[p] = true
+		// The pivot row has no bits in the previous columns.
+		for i := range m {
+			if pivot[i] || m[i][w]&bit == 0 {
+				continue
+			}
+			for k := w; k < len(m[i]); k++ {
+				m[i][k] ^= m[p][k]
+			}
+			for k := range hist[i] {
+				hist[i][k] ^= hist[p][k]
+			}
+		}
+	}
+
+	var deps [][]int
+	for i := range m {
+		if pivot[i] {
+			continue
+		}
+		var dep []int
+		for k, word := range hist[i] {
+			for ; word != 0; word &= word - 1 {
+				dep = append(dep, 64*k+bits.TrailingZeros64(word))
+			}
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// invMod returns a^-1 mod p for a prime p that does not divide a.
+func invMod(a, p uint64) uint64 {
+	t, newT := int64(0), int64(1)
+	r, newR := int64(p), int64(a%p)
+	for newR != 0 {
+		q := r / newR
+		t, newT = newT, t-q*newT
+		r, newR = newR, r-q*newR
+	}
+	if t < 0 {
+		t += int64(p)
+	}
+	return uint64(t)
+}
+
+// absDiff returns |a - b|.
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// randIntn returns a uniform random integer in [0, n).
+func randIntn(n int) int {
+	r, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(r.Int64())
+}
+
+// trailingZeroBits returns the number of the lowest zero bits of x != 0.
+// big.Int.TrailingZeroBits is not available before Go 1.13.
+func trailingZeroBits(x *big.Int) uint {
+	var i uint
+	for x.Bit(int(i)) == 0 {
+		i++
+	}
+	return i
+}
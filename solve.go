@@ -0,0 +1,306 @@
+package dhpals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// SolveMethod is the algorithm used to solve a part of a discrete logarithm.
+type SolveMethod int
+
+const (
+	// SolveExhaustive is the exhaustive search (ExhaustiveSearch).
+	SolveExhaustive SolveMethod = iota
+	// SolveBSGS is the baby-step giant-step algorithm (bsgsOrder).
+	SolveBSGS
+	// SolveRho is Pollard's rho (pollardRho).
+	SolveRho
+	// SolveKangaroo is the parallel kangaroo algorithm (catchKangarooParallel).
+	SolveKangaroo
+)
+
+func (m SolveMethod) String() string {
+	switch m {
+	case SolveExhaustive:
+		return "exhaustive search"
+	case SolveBSGS:
+		return "baby-step giant-step"
+	case SolveRho:
+		return "Pollard's rho"
+	case SolveKangaroo:
+		return "Pollard's kangaroo"
+	default:
+		return fmt.Sprintf("SolveMethod(%d)", int(m))
+	}
+}
+
+// SolveOptions contains the known information about the logarithm and the limits
+// of the methods. A nil *SolveOptions uses the defaults.
+type SolveOptions struct {
+	// OrderFactors is the factorization of the order of g or its multiple.
+	// If it is nil, p-1 is factored using Q and the known factors of the cofactor of the group.
+	OrderFactors []dhgroup.Factor
+
+	// If Lower and Upper are set, the logarithm is known to be in [Lower, Upper].
+	// They must be set together.
+	// Then the prime powers that are expensive compared to the interval are not solved
+	// separately: the rest of the logarithm is found with the kangaroo algorithm.
+	Lower, Upper *big.Int
+
+	// ExhaustiveBound is the largest prime solved by exhaustive search. Zero means 2^8.
+	ExhaustiveBound int64
+	// BSGSBound is the largest prime solved by BSGS; the larger ones are solved by rho.
+	// Zero means 2^36.
+	BSGSBound int64
+
+	// Context cancels the factorization of the group order and the kangaroo algorithm.
+	// Nil means context.Background().
+	Context context.Context
+}
+
+// SolvePart describes how the logarithm modulo a factor of the order was found.
+type SolvePart struct {
+	// Modulus is the prime power l^e for the Pohlig-Hellman parts. For the kangaroo
+	// part it is the product of the prime powers not solved separately.
+	Modulus *big.Int
+	Residue *big.Int
+	Method  SolveMethod
+}
+
+// SolveReport describes the solution found by Solve.
+type SolveReport struct {
+	// Order is the order of g.
+	Order *big.Int
+	Parts []SolvePart
+}
+
+const (
+	defaultExhaustiveBound = 1 << 8
+	defaultBSGSBound       = 1 << 36
+)
+
+// Solve finds x such that g^x = y mod p for the group modulus p.
+//
+// It finds the order n of g and splits the logarithm into the logarithms modulo the prime
+// powers l^e dividing n (Pohlig-Hellman). Each of them is computed digit by digit in the
+// subgroup of order l with exhaustive search, BSGS or rho depending on the size of l.
+// The parts are combined with crt. If the logarithm is known to be in an interval,
+// the largest prime powers may be skipped and the rest is found with the kangaroo algorithm.
+func Solve(group *dhgroup.GroupParams, g, y *big.Int, opts *SolveOptions) (*big.Int, *SolveReport, error) {
+	if opts == nil {
+		opts = new(SolveOptions)
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p := group.P
+	if (opts.Lower == nil) != (opts.Upper == nil) {
+		return nil, nil, errors.New("solve: only one of Lower and Upper is set")
+	}
+
+	factors := opts.OrderFactors
+	if factors == nil {
+		var err error
+		factors, err = groupOrderFactors(ctx, group)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	n, factors := reduceOrder(ModPGroup{p}, g, factorsProduct(factors), factors)
+	if new(big.Int).Exp(g, n, p).Cmp(Big1) != 0 {
+		return nil, nil, errors.New("solve: the factorization does not match the order of g")
+	}
+	if new(big.Int).Exp(y, n, p).Cmp(Big1) != 0 {
+		return nil, nil, errors.New("solve: y is not in the subgroup generated by g")
+	}
+	report := &SolveReport{Order: n}
+
+	// Solve the small primes first.
+	sort.Slice(factors, func(i, j int) bool {
+		return factors[i].Prime.Cmp(factors[j].Prime) < 0
+	})
+
+	var width *big.Int
+	if opts.Lower != nil && opts.Upper != nil {
+		width = new(big.Int).Sub(opts.Upper, opts.Lower)
+		if width.Sign() < 0 {
+			return nil, nil, errors.New("solve: empty interval")
+		}
+	}
+
+	var A, N []*big.Int
+	solved := big.NewInt(1)
+	for i, f := range factors {
+		// x = r + N*t, where r = x mod N is known and t is in an interval of width w/N.
+		// Once l*N exceeds w, the kangaroo finds t faster than rho solves l alone.
+		if width != nil && new(big.Int).Mul(f.Prime, solved).Cmp(width) > 0 {
+			x, err := solveKangarooPart(opts, p, g, y, A, N, solved, factors[i:])
+			if err != nil {
+				return nil, nil, err
+			}
+			report.Parts = append(report.Parts, x)
+			A = append(A, x.Residue)
+			N = append(N, x.Modulus)
+			break
+		}
+
+		part, err := solvePrimePower(opts, p, g, y, n, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		report.Parts = append(report.Parts, part)
+		A = append(A, part.Residue)
+		N = append(N, part.Modulus)
+		solved.Mul(solved, part.Modulus)
+	}
+
+	if len(A) == 0 {
+		// g = 1.
+		return new(big.Int), report, nil
+	}
+	x, _, err := crt(A, N)
+	if err != nil {
+		return nil, nil, err
+	}
+	if new(big.Int).Exp(g, x, p).Cmp(y) != 0 {
+		return nil, nil, errors.New("solve: wrong solution")
+	}
+	return x, report, nil
+}
+
+// solvePrimePower finds x mod l^e, where l^e is the largest power of l dividing n.
+func solvePrimePower(opts *SolveOptions, p, g, y, n *big.Int, f dhgroup.Factor) (SolvePart, error) {
+	method := primeMethod(opts, f.Prime)
+	solve := func(_ DLPGroup, g, y Element, l *big.Int) (*big.Int, error) {
+		return solvePrime(method, p, g.(*big.Int), y.(*big.Int), l)
+	}
+	x, le, err := pohligHellmanPrimePower(ModPGroup{p}, g, y, n, f, solve)
+	if err != nil {
+		return SolvePart{}, err
+	}
+	return SolvePart{Modulus: le, Residue: x, Method: method}, nil
+}
+
+func primeMethod(opts *SolveOptions, l *big.Int) SolveMethod {
+	esBound, bsgsBound := opts.ExhaustiveBound, opts.BSGSBound
+	if esBound == 0 {
+		esBound = defaultExhaustiveBound
+	}
+	if bsgsBound == 0 {
+		bsgsBound = defaultBSGSBound
+	}
+	switch {
+	case l.Cmp(big.NewInt(esBound)) <= 0:
+		return SolveExhaustive
+	case l.Cmp(big.NewInt(bsgsBound)) <= 0:
+		return SolveBSGS
+	default:
+		return SolveRho
+	}
+}
+
+// solvePrime finds the logarithm of y to the base g of prime order l.
+func solvePrime(method SolveMethod, p, g, y, l *big.Int) (*big.Int, error) {
+	switch method {
+	case SolveExhaustive:
+		return ExhaustiveSearch(ModPGroup{p}, g, y, l)
+	case SolveBSGS:
+		x, err := bsgsOrder(g, y, p, l)
+		if err != nil {
+			return nil, err
+		}
+		return x.Mod(x, l), nil
+	default:
+		return pollardRho(p, g, y, l, rhoBrent)
+	}
+}
+
+// solveKangarooPart finds the logarithm modulo the product of the remaining prime powers.
+// x = r + N*t for the logarithm r mod N found so far, so t is in
+// [(Lower - r)/N, (Upper - r)/N] and (g^N)^t = y*g^-r.
+func solveKangarooPart(opts *SolveOptions, p, g, y *big.Int, A, N []*big.Int, solved *big.Int, rest []dhgroup.Factor) (SolvePart, error) {
+	r := new(big.Int)
+	if len(A) > 0 {
+		var err error
+		r, _, err = crt(A, N)
+		if err != nil {
+			return SolvePart{}, err
+		}
+	}
+
+	a := new(big.Int).Sub(opts.Lower, r)
+	a.Div(a, solved)
+	if a.Sign() < 0 {
+		a.SetInt64(0)
+	}
+	b := new(big.Int).Sub(opts.Upper, r)
+	b.Div(b, solved)
+	b.Add(b, Big1)
+
+	gN := new(big.Int).Exp(g, solved, p)
+	yr := new(big.Int).Exp(g, new(big.Int).Neg(r), p)
+	yr.Mul(yr, y)
+	yr.Mod(yr, p)
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	t, _, err := catchKangarooParallel(ctx, p, gN, yr, a, b, kangarooConfig{})
+	if err != nil {
+		return SolvePart{}, err
+	}
+
+	m := big.NewInt(1)
+	for _, f := range rest {
+		m.Mul(m, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exp)), nil))
+	}
+	x := t.Mul(t, solved)
+	x.Add(x, r)
+	return SolvePart{Modulus: m, Residue: x.Mod(x, m), Method: SolveKangaroo}, nil
+}
+
+// groupOrderFactors returns the factorization of p-1 = Q*cofactor using the known
+// factors of the cofactor. The unknown parts are factored with factorizeWith.
+// Its probable prime factors are not proven: a composite one makes Solve fail
+// the final check g^x = y rather than return a wrong logarithm.
+func groupOrderFactors(ctx context.Context, group *dhgroup.GroupParams) ([]dhgroup.Factor, error) {
+	known, rest := group.CofactorFactors()
+	exps := make(map[string]int)
+	primes := make(map[string]*big.Int)
+	add := func(l *big.Int, e int) {
+		exps[l.String()] += e
+		primes[l.String()] = l
+	}
+	for _, f := range known {
+		add(f.Prime, f.Exp)
+	}
+
+	for _, m := range []*big.Int{group.Q, rest} {
+		if m.Cmp(Big1) == 0 {
+			continue
+		}
+		f, err := factorizeWith(ctx, m, factorConfig{})
+		if err != nil {
+			return nil, err
+		}
+		if f.Remainder.Cmp(Big1) != 0 {
+			return nil, fmt.Errorf("solve: the group order has an unfactored part %d", f.Remainder)
+		}
+		for _, pf := range append(f.Factors, f.Probable...) {
+			add(pf.fact, int(pf.exp))
+		}
+	}
+
+	var factors []dhgroup.Factor
+	for k, l := range primes {
+		factors = append(factors, dhgroup.Factor{Prime: l, Exp: exps[k]})
+	}
+	return factors, nil
+}
@@ -0,0 +1,128 @@
+package dhpals
+
+import (
+	"crypto/rand"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestSolve(t *testing.T) {
+	group, _, err := dhgroup.GenerateWeakGroup(mrand.New(mrand.NewSource(20)),
+		dhgroup.WeakGroupConfig{QBits: 36, SmoothnessBound: 1 << 12, SmallFactors: 4})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	pm1 := new(big.Int).Sub(group.P, Big1)
+	h, _ := rand.Int(rand.Reader, pm1)
+	h.Add(h, Big1)
+
+	for _, tt := range []struct {
+		name string
+		g    *big.Int
+		opts *SolveOptions
+		last SolveMethod
+	}{
+		{"subgroup", group.G, nil, SolveBSGS},
+		{"subgroup rho", group.G, &SolveOptions{BSGSBound: 1 << 10}, SolveRho},
+		{"random element", h, nil, SolveBSGS},
+	} {
+		x, _ := rand.Int(rand.Reader, pm1)
+		y := new(big.Int).Exp(tt.g, x, group.P)
+
+		xx, report, err := Solve(group, tt.g, y, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %s: %v", t.Name(), tt.name, err)
+		}
+		if new(big.Int).Exp(tt.g, xx, group.P).Cmp(y) != 0 || xx.Cmp(new(big.Int).Mod(x, report.Order)) != 0 {
+			t.Fatalf("%s: %s: want %d, got %d", t.Name(), tt.name, x, xx)
+		}
+
+		n := big.NewInt(1)
+		for _, part := range report.Parts {
+			t.Logf("%s: %s: x = %d mod %d (%s)", t.Name(), tt.name, part.Residue, part.Modulus, part.Method)
+			n.Mul(n, part.Modulus)
+		}
+		if n.Cmp(report.Order) != 0 {
+			t.Errorf("%s: %s: the parts do not cover the order %d", t.Name(), tt.name, report.Order)
+		}
+		// Q is the largest prime factor.
+		if m := report.Parts[len(report.Parts)-1].Method; m != tt.last {
+			t.Errorf("%s: %s: Q was solved by %s, want %s", t.Name(), tt.name, m, tt.last)
+		}
+	}
+
+	// p-1 has order 2, which is not in the subgroup of order Q.
+	if _, _, err := Solve(group, group.G, pm1, nil); err == nil {
+		t.Errorf("%s: expected an error for y outside the subgroup", t.Name())
+	}
+
+	// The factors of the cofactor do not include the order Q of g.
+	factors, _ := group.CofactorFactors()
+	if _, _, err := Solve(group, group.G, group.G, &SolveOptions{OrderFactors: factors}); err == nil {
+		t.Errorf("%s: expected an error for a wrong factorization of the order", t.Name())
+	}
+
+	if _, _, err := Solve(group, group.G, group.G, &SolveOptions{Lower: Big1}); err == nil {
+		t.Errorf("%s: expected an error for an interval without the upper bound", t.Name())
+	}
+}
+
+func TestSolveInterval(t *testing.T) {
+	group := dhgroup.MODP512V57().DHParams()
+	lower := big.NewInt(1 << 20)
+	upper := new(big.Int).Lsh(Big1, 36)
+	x, _ := rand.Int(rand.Reader, new(big.Int).Sub(upper, lower))
+	x.Add(x, lower)
+	y := group.ScalarBaseExp(x)
+
+	xx, report, err := Solve(group, group.G, y, &SolveOptions{
+		OrderFactors: []dhgroup.Factor{{Prime: group.Q, Exp: 1}},
+		Lower:        lower,
+		Upper:        upper,
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if xx.Cmp(x) != 0 {
+		t.Fatalf("%s: want %d, got %d", t.Name(), x, xx)
+	}
+	if len(report.Parts) != 1 || report.Parts[0].Method != SolveKangaroo {
+		t.Errorf("%s: the logarithm must be found by the kangaroo algorithm", t.Name())
+	}
+}
+
+func TestSolveSmallFactorsAndInterval(t *testing.T) {
+	group, _, err := dhgroup.GenerateWeakGroup(mrand.New(mrand.NewSource(20)),
+		dhgroup.WeakGroupConfig{QBits: 64, SmoothnessBound: 1 << 8, SmallFactors: 3})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	pm1 := new(big.Int).Sub(group.P, Big1)
+	h, _ := rand.Int(rand.Reader, pm1)
+	h.Add(h, Big1)
+
+	upper := new(big.Int).Lsh(Big1, 44)
+	x, _ := rand.Int(rand.Reader, upper)
+	y := new(big.Int).Exp(h, x, group.P)
+
+	xx, report, err := Solve(group, h, y, &SolveOptions{Lower: new(big.Int), Upper: upper})
+	if err != nil {
+		t.Fatalf("%s: %v", t.Name(), err)
+	}
+	if report.Order.Cmp(upper) <= 0 {
+		t.Skipf("%s: the order of h is too small", t.Name())
+	}
+	if xx.Cmp(x) != 0 {
+		t.Fatalf("%s: want %d, got %d", t.Name(), x, xx)
+	}
+
+	for i, part := range report.Parts {
+		t.Logf("%s: x = %d mod %d (%s)", t.Name(), part.Residue, part.Modulus, part.Method)
+		if kangaroo := i == len(report.Parts)-1; kangaroo != (part.Method == SolveKangaroo) {
+			t.Errorf("%s: only the last part must be found by the kangaroo algorithm", t.Name())
+		}
+	}
+}
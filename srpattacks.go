@@ -0,0 +1,59 @@
+package dhpals
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+// runSRPZeroKeyAttack logs in to an SRP server that does not check A mod N
+// without knowing the password. For A = k*N the server computes
+// S = (A*v^u)^b = 0 mod N, so the client proof only depends on public values.
+// It returns the session key or nil if the login failed.
+func runSRPZeroKeyAttack(group dhgroup.DHScheme, identity string, k int64,
+	hello func(string, *big.Int) ([]byte, *big.Int), login func([]byte) ([]byte, bool)) (key []byte) {
+
+	srp := &dhgroup.SRP{Group: group}
+	A := new(big.Int).Mul(big.NewInt(k), group.DHParams().P)
+
+	salt, B := hello(identity, A)
+	key = srp.SessionKey(new(big.Int))
+	m1 := srp.ClientProof(identity, salt, A, B, key)
+
+	m2, ok := login(m1)
+	if !ok || !bytes.Equal(m2, srp.ServerProof(A, m1, key)) {
+		return nil
+	}
+	return key
+}
+
+// runSimplifiedSRPDictionaryAttack impersonates the server of the simplified SRP
+// to the client. Mallory sends B = g and u = 1 and gets A and the client's MAC.
+// Then S = B^(a + u*x) = A * g^x, so every password candidate can be checked offline.
+// It returns the password or an empty string if it is not in the dictionary.
+func runSimplifiedSRPDictionaryAttack(group dhgroup.DHScheme,
+	login func([]byte, *big.Int, *big.Int) (*big.Int, []byte), dictionary []string) (password string) {
+
+	params := group.DHParams()
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+
+	A, mac := login(salt, params.G, big.NewInt(1))
+
+	S := new(big.Int)
+	for _, w := range dictionary {
+		h := sha256.Sum256(append(append([]byte(nil), salt...), w...))
+		S.Exp(params.G, new(big.Int).SetBytes(h[:]), params.P)
+		S.Mul(S, A)
+		S.Mod(S, params.P)
+		if bytes.Equal(simplifiedSRPMAC(S, salt), mac) {
+			return w
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package dhpals
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dnkolegov/dhpals/dhgroup"
+)
+
+func TestSRPZeroKeyAttack(t *testing.T) {
+	group := dhgroup.SRP2048()
+
+	for _, k := range []int64{0, 1, 2, 5} {
+		hello, login, isKeyCorrect := newSRPServerOracle(dhgroup.Srp2048, "alice")
+
+		key := runSRPZeroKeyAttack(group, "alice", k, hello, login)
+		if key == nil || !isKeyCorrect(key) {
+			t.Errorf("%s: login with A = %d*N failed", t.Name(), k)
+		}
+	}
+}
+
+func TestSimplifiedSRPDictionaryAttack(t *testing.T) {
+	dictionary := []string{"123456", "password", "qwerty", "letmein", "dragon", "monkey", "iloveyou"}
+	for i := 0; i < 1000; i++ {
+		dictionary = append(dictionary, fmt.Sprintf("password%d", i))
+	}
+
+	login, isPasswordCorrect := newSimplifiedSRPOracle(dhgroup.Srp1024, dictionary)
+
+	password := runSimplifiedSRPDictionaryAttack(dhgroup.SRP1024(), login, dictionary)
+	t.Logf("%s: Password: %q\n", t.Name(), password)
+
+	if !isPasswordCorrect(password) {
+		t.Fatalf("%s: wrong password was found in the dictionary attack", t.Name())
+	}
+}